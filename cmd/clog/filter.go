@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/telepresenceio/clog/handler"
+)
+
+type attrMatch struct {
+	path  []string
+	value string
+}
+
+// filter decides which records are shown. The zero filter matches all records.
+type filter struct {
+	level *slog.Level
+	group string
+	since time.Time
+	until time.Time
+	attrs []attrMatch
+	grep  *regexp.Regexp
+}
+
+func (f *filter) match(r *handler.ParsedRecord) bool {
+	if f.level != nil && r.Level < *f.level {
+		return false
+	}
+	if f.group != "" {
+		g := strings.Join(r.Groups, "/")
+		if g != f.group && !strings.HasPrefix(g, f.group+"/") {
+			return false
+		}
+	}
+	if !f.since.IsZero() && r.Time.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && !r.Time.Before(f.until) {
+		return false
+	}
+	for _, am := range f.attrs {
+		if !hasAttr(r, am) {
+			return false
+		}
+	}
+	return f.grep == nil || f.grep.MatchString(r.Message)
+}
+
+func hasAttr(r *handler.ParsedRecord, am attrMatch) bool {
+	found := false
+	r.Attrs(func(a slog.Attr) bool {
+		found = matchAttr(a, am.path, am.value)
+		return !found
+	})
+	return found
+}
+
+func matchAttr(a slog.Attr, path []string, value string) bool {
+	if a.Key != path[0] {
+		return false
+	}
+	v := a.Value.Resolve()
+	if len(path) == 1 {
+		return v.Kind() != slog.KindGroup && v.String() == value
+	}
+	if v.Kind() != slog.KindGroup {
+		return false
+	}
+	for _, ga := range v.Group() {
+		if matchAttr(ga, path[1:], value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/telepresenceio/clog/handler"
+	"github.com/telepresenceio/clog/internal"
+)
+
+// followInterval is how often a followed file is checked for appended data.
+const followInterval = 250 * time.Millisecond
+
+type input struct {
+	name string
+	io.Reader
+	io.Closer
+}
+
+// source is a file, preceded by its rotated files when they are read.
+type source struct {
+	name   string
+	inputs []*input
+}
+
+// openSources opens the given files, or the standard input if no files are given. When rotated is true,
+// the inputs of each source start with the rotated files of the file. When follow is true, the last input
+// never ends, unless the context is cancelled.
+func openSources(ctx context.Context, files []string, stdin io.Reader, rotated, follow bool) ([]*source, error) {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	var sources []*source
+	closeAll := func() {
+		for _, src := range sources {
+			src.Close()
+		}
+	}
+	names := make(map[string]int, len(files))
+	for i, file := range files {
+		src := &source{name: originName(file)}
+		if n := names[src.name]; n > 0 {
+			names[src.name] = n + 1
+			src.name += "#" + strconv.Itoa(n+1)
+		} else {
+			names[src.name] = 1
+		}
+		sources = append(sources, src)
+		var paths []string
+		if rotated && file != "-" {
+			paths = rotatedFiles(file)
+		}
+		paths = append(paths, file)
+		for j, path := range paths {
+			in, err := openInput(ctx, path, stdin, follow && i == len(files)-1 && j == len(paths)-1)
+			if err != nil {
+				closeAll()
+				return nil, err
+			}
+			src.inputs = append(src.inputs, in)
+		}
+	}
+	return sources, nil
+}
+
+// originName returns the name of the file without directory, ".gz" suffix, and extension.
+func originName(file string) string {
+	if file == "-" {
+		return "stdin"
+	}
+	name := strings.TrimSuffix(filepath.Base(file), ".gz")
+	if ext := filepath.Ext(name); ext != name {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// records returns an iterator over the records of all inputs of the source. Each input is closed
+// once it has been read.
+func (s *source) records(timeFormat string, loc *time.Location) iter.Seq2[*handler.ParsedRecord, error] {
+	return func(yield func(*handler.ParsedRecord, error) bool) {
+		for _, in := range s.inputs {
+			for r, err := range in.records(timeFormat, loc) {
+				if err != nil {
+					err = fmt.Errorf("%s: %w", in.name, err)
+				}
+				if !yield(r, err) {
+					return
+				}
+			}
+			_ = in.Close()
+		}
+	}
+}
+
+// Close closes all inputs of the source.
+func (s *source) Close() {
+	for _, in := range s.inputs {
+		_ = in.Close()
+	}
+}
+
+func openInput(ctx context.Context, name string, stdin io.Reader, follow bool) (*input, error) {
+	if name == "-" {
+		return &input{name: "stdin", Reader: stdin, Closer: io.NopCloser(nil)}, nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		r  io.Reader = f
+		fr *followReader
+	)
+	if follow {
+		fr = &followReader{ctx: ctx, f: f, reportIdle: true}
+		r = fr
+	}
+	br := bufio.NewReader(r)
+	if magic := peek(br, 2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		if fr != nil {
+			// The decompressor doesn't survive errors, so it must wait for the data.
+			fr.reportIdle = false
+		}
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return &input{name: name, Reader: zr, Closer: f}, nil
+	}
+	return &input{name: name, Reader: br, Closer: f}, nil
+}
+
+// rotatedFiles returns the existing files named name.N or name.N.gz, where N is a number, ordered by
+// decreasing N, i.e. oldest first.
+func rotatedFiles(name string) []string {
+	matches, _ := filepath.Glob(name + ".*")
+	type numbered struct {
+		name string
+		n    int
+	}
+	var rs []numbered
+	for _, m := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(m, name+"."), ".gz")
+		if n, err := strconv.Atoi(suffix); err == nil {
+			rs = append(rs, numbered{name: m, n: n})
+		}
+	}
+	slices.SortFunc(rs, func(a, b numbered) int { return b.n - a.n })
+	files := make([]string, len(rs))
+	for i, r := range rs {
+		files[i] = r.name
+	}
+	return files
+}
+
+// records returns an iterator over the records of the input, which are parsed as JSON if the input starts
+// with '{', and as output of the clog text handler otherwise.
+func (in *input) records(timeFormat string, loc *time.Location) iter.Seq2[*handler.ParsedRecord, error] {
+	br := bufio.NewReader(in.Reader)
+	if first := peek(br, 1); len(first) == 1 && first[0] == '{' {
+		return handler.ParseJSON(br)
+	}
+	return handler.ParseText(br, handler.ParseTimeFormat(timeFormat), handler.ParseLocation(loc))
+}
+
+// peek returns the next n bytes of the reader, or fewer at the end of the input. Unlike [bufio.Reader.Peek],
+// it waits for the bytes when the reader is idle.
+func peek(br *bufio.Reader, n int) []byte {
+	for {
+		b, err := br.Peek(n)
+		if !errors.Is(err, internal.ErrIdle) {
+			return b
+		}
+	}
+}
+
+// followReader reads a file that is being appended to. It waits for more data instead of returning io.EOF,
+// and starts over from the beginning if the file is truncated. When reportIdle is true, it returns
+// [internal.ErrIdle] once each time it runs out of data before it starts waiting, so that the parsers don't
+// hold on to the last record.
+type followReader struct {
+	ctx        context.Context
+	f          *os.File
+	reportIdle bool
+	idle       bool
+}
+
+func (fr *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := fr.f.Read(p)
+		if n > 0 || err != io.EOF {
+			fr.idle = false
+			return n, err
+		}
+		if fi, err := fr.f.Stat(); err == nil {
+			if off, err := fr.f.Seek(0, io.SeekCurrent); err == nil && fi.Size() < off {
+				if _, err = fr.f.Seek(0, io.SeekStart); err != nil {
+					return 0, err
+				}
+				continue
+			}
+		}
+		if fr.reportIdle && !fr.idle {
+			fr.idle = true
+			return 0, internal.ErrIdle
+		}
+		select {
+		case <-fr.ctx.Done():
+			return 0, io.EOF
+		case <-time.After(followInterval):
+		}
+	}
+}
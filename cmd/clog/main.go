@@ -0,0 +1,297 @@
+// Command clog reads logs written by the clog text handler or by a slog JSON handler, filters their records,
+// and renders them as text, colored text, JSON, or logfmt. The text output is produced by the handler package,
+// so it is formatted exactly like the logs of the programs that use it.
+//
+// Usage:
+//
+//	clog [flags] [file ...]
+//	clog merge [flags] [-offset NAME=DURATION ...] file ...
+//
+// The standard input is read when no files are given, or when a file is "-". Files compressed with gzip are
+// decompressed, and the format of each file is detected from its first character. The flags are:
+//
+//	-level LEVEL       only show records at or above LEVEL
+//	-group PREFIX      only show records in the group PREFIX or one of its subgroups, e.g. "daemon/session"
+//	-since TIME        only show records logged at or after TIME
+//	-until TIME        only show records logged before TIME
+//	-attr KEY=VALUE    only show records with an attribute KEY equal to VALUE; nested keys are separated by '.'
+//	-grep REGEXP       only show records with a message that matches REGEXP
+//	-format FORMAT     the output format: "text", "color", "json", or "logfmt"; the default is "color" on a terminal
+//	-timeformat LAYOUT the time format of text input and output; the default is "2006-01-02T15:04:05.000"
+//	-tz [NAME=]LOC     the location of text timestamps, which lack a time zone; the default is "Local"
+//	-rotated           also read the rotated files of each file, named FILE.N or FILE.N.gz, oldest first
+//	-follow            wait for more records to be appended to the last file
+//
+// A TIME is either an RFC 3339 timestamp, a timestamp in the -timeformat layout, or a duration such as "15m",
+// meaning that long ago. The -attr flag can be repeated, and all attributes must match.
+//
+// The merge command merges the records of several files, e.g. the logs of cooperating processes, into one
+// stream ordered by time. Each record is tagged with its origin, which is the name of its file without
+// directory and extension. The files are read like the files of the first form, except that -follow isn't
+// supported, and that the -offset flag is accepted:
+//
+//	-offset NAME=DURATION  add DURATION, e.g. "-1.5s", to the timestamps of the file with origin NAME
+//
+// The -tz flag can also be given as NAME=LOC, to set the location of the timestamps of the file with origin
+// NAME, which is useful when the processes ran in different time zones. The timestamps of the merged stream
+// are written in the location given without NAME. Both flags can be repeated.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/telepresenceio/clog"
+	"github.com/telepresenceio/clog/handler"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+type options struct {
+	filter     filter
+	format     string
+	timeFormat string
+	location   *time.Location
+	locations  map[string]*time.Location
+	offsets    map[string]time.Duration
+	rotated    bool
+	follow     bool
+}
+
+// locationOf returns the location of the text timestamps of the source with the given origin name.
+func (o *options) locationOf(name string) *time.Location {
+	if loc, ok := o.locations[name]; ok {
+		return loc
+	}
+	return o.location
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	merge := len(args) > 0 && args[0] == "merge"
+	if merge {
+		args = args[1:]
+	}
+	opts, files, err := parseFlags(args, stderr, merge)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if opts.format == "" {
+		opts.format = "text"
+		if isTerminal(stdout) {
+			opts.format = "color"
+		}
+	}
+	sources, err := openSources(ctx, files, stdin, opts.rotated, opts.follow)
+	if err != nil {
+		fmt.Fprintln(stderr, "clog:", err)
+		return 1
+	}
+	defer func() {
+		for _, src := range sources {
+			src.Close()
+		}
+	}()
+	originWidth := 0
+	if merge {
+		for _, src := range sources {
+			originWidth = max(originWidth, len(src.name))
+		}
+	}
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+	render, err := newRenderer(opts.format, out, opts.timeFormat, originWidth)
+	if err != nil {
+		fmt.Fprintln(stderr, "clog:", err)
+		return 2
+	}
+
+	var records iter.Seq2[*handler.ParsedRecord, error]
+	if merge {
+		inputs := make([]handler.MergeInput, len(sources))
+		for i, src := range sources {
+			inputs[i] = handler.MergeInput{
+				Name:    src.name,
+				Records: src.records(opts.timeFormat, opts.locationOf(src.name)),
+				Offset:  opts.offsets[src.name],
+			}
+		}
+		records = handler.Merge(inputs...)
+	} else {
+		records = func(yield func(*handler.ParsedRecord, error) bool) {
+			for _, src := range sources {
+				for r, err := range src.records(opts.timeFormat, opts.locationOf(src.name)) {
+					if !yield(r, err) {
+						return
+					}
+				}
+			}
+		}
+	}
+
+	status := 0
+	for r, err := range records {
+		if err != nil {
+			fmt.Fprintln(stderr, "clog:", err)
+			status = 1
+			continue
+		}
+		if !opts.filter.match(r) {
+			continue
+		}
+		if merge {
+			// Show all timestamps of the merged stream in the same location.
+			r.Time = r.Time.In(opts.location)
+		}
+		if err = render(ctx, r); err != nil {
+			fmt.Fprintln(stderr, "clog:", err)
+			return 1
+		}
+		if opts.follow {
+			_ = out.Flush()
+		}
+	}
+	return status
+}
+
+func parseFlags(args []string, stderr io.Writer, merge bool) (*options, []string, error) {
+	opts := &options{
+		location:  time.Local,
+		locations: make(map[string]*time.Location),
+		offsets:   make(map[string]time.Duration),
+	}
+	var since, until string
+	name := "clog"
+	if merge {
+		name = "clog merge"
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		if merge {
+			fmt.Fprintln(stderr, "usage: clog merge [flags] file ...")
+		} else {
+			fmt.Fprintln(stderr, "usage: clog [flags] [file ...]\n       clog merge [flags] file ...")
+		}
+		fs.PrintDefaults()
+	}
+	fs.Func("level", "only show records at or above `LEVEL`", func(s string) error {
+		l, err := clog.ParseLevel(s)
+		if err == nil {
+			opts.filter.level = &l
+		}
+		return err
+	})
+	fs.StringVar(&opts.filter.group, "group", "", "only show records in the group `PREFIX` or one of its subgroups")
+	fs.StringVar(&since, "since", "", "only show records logged at or after `TIME`")
+	fs.StringVar(&until, "until", "", "only show records logged before `TIME`")
+	fs.Func("attr", "only show records with an attribute `KEY=VALUE`; may be repeated", func(s string) error {
+		k, v, ok := strings.Cut(s, "=")
+		if !ok || k == "" {
+			return errors.New("must be KEY=VALUE")
+		}
+		opts.filter.attrs = append(opts.filter.attrs, attrMatch{path: strings.Split(k, "."), value: v})
+		return nil
+	})
+	fs.Func("grep", "only show records with a message that matches `REGEXP`", func(s string) (err error) {
+		opts.filter.grep, err = regexp.Compile(s)
+		return err
+	})
+	fs.StringVar(&opts.format, "format", "", "the output `FORMAT`: text, color, json, or logfmt")
+	fs.StringVar(&opts.timeFormat, "timeformat", handler.RFC3339MillisNoTz, "the time format `LAYOUT` of text input and output")
+	fs.Func("tz", "the `[NAME=]LOCATION` of text timestamps, for all files or for the file with origin NAME", func(s string) error {
+		name, locName, ok := strings.Cut(s, "=")
+		if !ok {
+			locName = name
+		}
+		loc, err := time.LoadLocation(locName)
+		if err != nil {
+			return err
+		}
+		if ok {
+			opts.locations[name] = loc
+		} else {
+			opts.location = loc
+		}
+		return nil
+	})
+	fs.BoolVar(&opts.rotated, "rotated", false, "also read the rotated files of each file, oldest first")
+	if merge {
+		fs.Func("offset", "add `NAME=DURATION` to the timestamps of the file with origin NAME", func(s string) error {
+			name, ds, ok := strings.Cut(s, "=")
+			if !ok || name == "" {
+				return errors.New("must be NAME=DURATION")
+			}
+			d, err := time.ParseDuration(ds)
+			if err == nil {
+				opts.offsets[name] = d
+			}
+			return err
+		})
+	} else {
+		fs.BoolVar(&opts.follow, "follow", false, "wait for more records to be appended to the last file")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	fail := func(err error) (*options, []string, error) {
+		fmt.Fprintln(stderr, "clog:", err)
+		fs.Usage()
+		return nil, nil, err
+	}
+	var err error
+	now := time.Now()
+	if since != "" {
+		if opts.filter.since, err = parseTime(since, opts.timeFormat, opts.location, now); err != nil {
+			return fail(fmt.Errorf("invalid -since: %w", err))
+		}
+	}
+	if until != "" {
+		if opts.filter.until, err = parseTime(until, opts.timeFormat, opts.location, now); err != nil {
+			return fail(fmt.Errorf("invalid -until: %w", err))
+		}
+	}
+	return opts, fs.Args(), nil
+}
+
+// parseTime parses a TIME argument, which is an RFC 3339 timestamp, a timestamp using the given layout,
+// or a duration that is subtracted from now.
+func parseTime(s, layout string, loc *time.Location, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	if layout != "" {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is neither a timestamp nor a duration", s)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const textLog = `2026-01-02T03:04:05.000 INFO  daemon: starting : version=1.2
+2026-01-02T03:04:06.000 DEBUG daemon/session: connecting : addr=10.0.0.1 tls={enabled=true}
+2026-01-02T03:04:07.000 ERROR daemon/session: connection failed
+second line : addr=10.0.0.1 (from session.go:42)
+2026-01-02T03:04:08.000 WARN  agent: slow
+`
+
+const jsonLog = `{"time":"2026-01-02T03:04:05Z","level":"INFO","source":{"function":"main.main","file":"main.go","line":7},"msg":"hello","n":3,"g":{"a":"b"}}
+{"time":"2026-01-02T03:04:06Z","level":"DEBUG-4","msg":"trace"}
+`
+
+func writeFile(t *testing.T, dir, name, content string, compress bool) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	data := []byte(content)
+	if compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write(data)
+		_ = zw.Close()
+		data = buf.Bytes()
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	textFile := writeFile(t, dir, "daemon.log", textLog, false)
+	jsonFile := writeFile(t, dir, "app.json", jsonLog, false)
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "level",
+			args: []string{"-level", "warn", textFile},
+			want: `2026-01-02T03:04:07.000 ERROR daemon/session: connection failed
+second line : addr=10.0.0.1 (from session.go:42)
+2026-01-02T03:04:08.000 WARN  agent: slow
+`,
+		},
+		{
+			name: "group",
+			args: []string{"-group", "daemon/session", "-attr", "tls.enabled=true", textFile},
+			want: "2026-01-02T03:04:06.000 DEBUG daemon/session: connecting : addr=10.0.0.1 tls={enabled=true}\n",
+		},
+		{
+			name: "group prefix",
+			args: []string{"-group", "daemon", "-grep", "^start", textFile},
+			want: "2026-01-02T03:04:05.000 INFO  daemon: starting : version=1.2\n",
+		},
+		{
+			name: "time range",
+			args: []string{"-tz", "UTC", "-since", "2026-01-02T03:04:06Z", "-until", "2026-01-02T03:04:07.000", textFile},
+			want: "2026-01-02T03:04:06.000 DEBUG daemon/session: connecting : addr=10.0.0.1 tls={enabled=true}\n",
+		},
+		{
+			name: "json to text",
+			args: []string{"-tz", "UTC", "-timeformat", "15:04:05", jsonFile},
+			want: `03:04:05 INFO  hello : n=3 g={a=b} (from main.go:7)
+03:04:06 TRACE trace
+`,
+		},
+		{
+			name: "text to json",
+			args: []string{"-tz", "UTC", "-format", "json", "-attr", "addr=10.0.0.1", "-level", "error", textFile},
+			want: `{"time":"2026-01-02T03:04:07Z","level":"ERROR","msg":"connection failed\nsecond line","source":{"file":"session.go","line":42},"daemon":{"session":{"addr":"10.0.0.1"}}}
+`,
+		},
+		{
+			name: "logfmt",
+			args: []string{"-format", "logfmt", "-grep", "hello", jsonFile},
+			want: `time=2026-01-02T03:04:05.000Z level=INFO msg=hello source=main.go:7 n=3 g.a=b
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if status := run(context.Background(), tt.args, nil, &stdout, &stderr); status != 0 {
+				t.Fatalf("exit status %d: %s", status, stderr.String())
+			}
+			if got := stdout.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_rotated(t *testing.T) {
+	dir := t.TempDir()
+	lines := strings.SplitAfter(textLog, "\n")
+	writeFile(t, dir, "daemon.log.2.gz", lines[0], true)
+	writeFile(t, dir, "daemon.log.1", lines[1], false)
+	writeFile(t, dir, "daemon.log.old", "ignored\n", false)
+	file := writeFile(t, dir, "daemon.log", lines[4], false)
+
+	var stdout, stderr bytes.Buffer
+	if status := run(context.Background(), []string{"-format", "text", "-rotated", file}, nil, &stdout, &stderr); status != 0 {
+		t.Fatalf("exit status %d: %s", status, stderr.String())
+	}
+	want := lines[0] + lines[1] + lines[4]
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRun_stdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if status := run(context.Background(), []string{"-level", "info"}, strings.NewReader(textLog), &stdout, &stderr); status != 0 {
+		t.Fatalf("exit status %d: %s", status, stderr.String())
+	}
+	if n := strings.Count(stdout.String(), "\n"); n != 4 {
+		t.Errorf("got %d lines, want 4:\n%s", n, stdout.String())
+	}
+}
+
+// syncBuffer is a bytes.Buffer that can be read while it's written.
+type syncBuffer struct {
+	sync.Mutex
+	bytes.Buffer
+}
+
+func (b *syncBuffer) Write(data []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.Buffer.Write(data)
+}
+
+func (b *syncBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.Buffer.String()
+}
+
+func TestRun_follow(t *testing.T) {
+	lines := strings.SplitAfter(textLog, "\n")
+	file := writeFile(t, t.TempDir(), "daemon.log", lines[0], false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var stdout, stderr syncBuffer
+	done := make(chan int)
+	go func() {
+		done <- run(ctx, []string{"-format", "text", "-follow", file}, nil, &stdout, &stderr)
+	}()
+	waitFor := func(want string) {
+		t.Helper()
+		for deadline := time.Now().Add(10 * time.Second); stdout.String() != want; time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("got:\n%s\nwant:\n%s", stdout.String(), want)
+			}
+		}
+	}
+
+	// The last record is written without waiting for the next one.
+	waitFor(lines[0])
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(lines[1])
+	_ = f.Close()
+	waitFor(lines[0] + lines[1])
+
+	cancel()
+	if status := <-done; status != 0 {
+		t.Fatalf("exit status %d: %s", status, stderr.String())
+	}
+	if got, want := stdout.String(), lines[0]+lines[1]; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRun_merge(t *testing.T) {
+	dir := t.TempDir()
+	daemon := writeFile(t, dir, "daemon.log", `2026-01-02T03:04:05.000 INFO  connecting
+2026-01-02T03:04:05.300 ERROR connection failed
+with details : error=timeout
+`, false)
+	agent := writeFile(t, dir, "traffic-agent.log.gz", `2026-01-02T04:04:05.350 INFO  accepting
+garbage
+2026-01-02T04:04:05.600 INFO  accepted
+`, true)
+	api := writeFile(t, dir, "api.json", `{"time":"2026-01-02T03:04:05.200Z","level":"INFO","msg":"request"}
+`, false)
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"merge", "-format", "text", "-tz", "UTC", "-tz", "traffic-agent=Europe/Stockholm", "-offset", "traffic-agent=-500ms", daemon, agent, api}
+	if status := run(context.Background(), args, nil, &stdout, &stderr); status != 0 {
+		t.Fatalf("exit status %d: %s", status, stderr.String())
+	}
+	want := `traffic-agent 2026-01-02T03:04:04.850 INFO  accepting
+garbage
+daemon        2026-01-02T03:04:05.000 INFO  connecting
+traffic-agent 2026-01-02T03:04:05.100 INFO  accepted
+api           2026-01-02T03:04:05.200 INFO  request
+daemon        2026-01-02T03:04:05.300 ERROR connection failed
+with details : error=timeout
+`
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	stdout.Reset()
+	args = []string{"merge", "-format", "json", "-level", "error", daemon, api}
+	if status := run(context.Background(), args, nil, &stdout, &stderr); status != 0 {
+		t.Fatalf("exit status %d: %s", status, stderr.String())
+	}
+	if got := stdout.String(); !strings.Contains(got, `"msg":"connection failed\nwith details","origin":"daemon","error":"timeout"`) {
+		t.Errorf("unexpected output %s", got)
+	}
+}
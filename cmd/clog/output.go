@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+
+	"github.com/telepresenceio/clog/handler"
+	"github.com/telepresenceio/clog/internal"
+)
+
+// renderer writes a record.
+type renderer func(ctx context.Context, r *handler.ParsedRecord) error
+
+// newRenderer returns a renderer for the given format. The origin of each record is written first when
+// originWidth is positive, padded to originWidth in text formats, or as an "origin" attribute otherwise.
+func newRenderer(format string, w io.Writer, timeFormat string, originWidth int) (renderer, error) {
+	all := &slog.HandlerOptions{Level: slog.Level(math.MinInt)}
+	switch format {
+	case "text", "color":
+		h := handler.NewText(
+			handler.Output(w),
+			handler.TimeFormat(timeFormat),
+			handler.EnabledLevel(slog.Level(math.MinInt)),
+			handler.IncludeSource(true),
+			handler.Color(format == "color"))
+		return func(ctx context.Context, r *handler.ParsedRecord) error {
+			if originWidth > 0 {
+				if _, err := fmt.Fprintf(w, "%-*s ", originWidth, r.Origin); err != nil {
+					return err
+				}
+			}
+			return withGroups(h, r.Groups).Handle(internal.WithSource(ctx, r.Source), r.Record)
+		}, nil
+	case "json":
+		return slogRenderer(slog.NewJSONHandler(w, all), originWidth > 0), nil
+	case "logfmt":
+		return slogRenderer(slog.NewTextHandler(w, all), originWidth > 0), nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// slogRenderer renders records using a handler from the slog package. The source of the record is
+// added as the first attribute, which these handlers render like the source of a record with a PC.
+// The groups of the record are added as attributes, so that the source isn't part of them.
+func slogRenderer(h slog.Handler, withOrigin bool) renderer {
+	return func(ctx context.Context, r *handler.ParsedRecord) error {
+		if r.Source == nil && len(r.Groups) == 0 && !withOrigin {
+			return h.Handle(ctx, r.Record)
+		}
+		record := slog.NewRecord(r.Time, r.Level, r.Message, 0)
+		if withOrigin {
+			record.AddAttrs(slog.String("origin", r.Origin))
+		}
+		if r.Source != nil {
+			record.AddAttrs(slog.Any(slog.SourceKey, r.Source))
+		}
+		attrs := make([]slog.Attr, 0, r.NumAttrs())
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, a)
+			return true
+		})
+		for i := len(r.Groups) - 1; i >= 0 && len(attrs) > 0; i-- {
+			attrs = []slog.Attr{slog.GroupAttrs(r.Groups[i], attrs...)}
+		}
+		record.AddAttrs(attrs...)
+		return h.Handle(ctx, record)
+	}
+}
+
+func withGroups(h slog.Handler, groups []string) slog.Handler {
+	for _, g := range groups {
+		h = h.WithGroup(g)
+	}
+	return h
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	stdLog "log"
 	"log/slog"
+	"time"
 
 	"github.com/telepresenceio/clog/internal"
 )
@@ -87,8 +88,10 @@ func Logger(ctx context.Context) *slog.Logger {
 	return internal.Logger(ctx)
 }
 
-func StdLogger(ctx context.Context, level slog.Level) *stdLog.Logger {
-	return stdLog.New(&writer{log: Logger(ctx), level: level, ctx: ctx}, "", 0)
+// StdLogger returns a standard library logger that sends each line to the context logger at the given level.
+// The source of the records is the caller of the standard logger.
+func StdLogger(ctx context.Context, level slog.Level, opts ...StdLogOption) *stdLog.Logger {
+	return stdLog.New(newWriter(ctx, level, opts), "", 0)
 }
 
 // Trace is similar to [slog.Logger.Log] on the context logger, called with [slog.LevelTrace].
@@ -138,6 +141,13 @@ func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
 	return internal.WithLogger(ctx, logger)
 }
 
+// WithTimeOrigin assigns a time origin to a child context which is returned. Handlers that render the time of
+// records relative to an origin, such as a text handler created with the TimeSinceOrigin option of the handler
+// package, use it for records logged using the returned context and its children.
+func WithTimeOrigin(ctx context.Context, origin time.Time) context.Context {
+	return internal.WithTimeOrigin(ctx, origin)
+}
+
 // WithGroup creates a clone of the context logger, adds the group, and assigns the clone to a child context which is returned.
 func WithGroup(ctx context.Context, group string) context.Context {
 	if group == "" {
@@ -1,14 +1,20 @@
 package clog_test
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"fmt"
+	stdLog "log"
 	"log/slog"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/telepresenceio/clog"
 	"github.com/telepresenceio/clog/handler"
 	"github.com/telepresenceio/clog/internal"
+	"github.com/telepresenceio/clog/testutil"
 )
 
 func fakeTime() {
@@ -44,6 +50,50 @@ func ExampleNewText() {
 	// first/second: This is a warning!
 }
 
+func ExampleNewText_timeDelta() {
+	clock := testutil.NewFakeClock(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	ctx := testutil.WithClock(context.Background(), clock)
+	ctx = clog.WithLogger(ctx, slog.New(handler.NewText(handler.TimeDelta(), handler.EnabledLevel(slog.LevelInfo))))
+
+	clog.Info(ctx, "starting")
+	clock.Advance(15 * time.Millisecond)
+	clog.Info(clog.WithGroup(ctx, "config"), "loaded")
+	clock.Advance(1250 * time.Millisecond)
+	clog.Info(ctx, "connected")
+
+	// Output:
+	//    +0.000s INFO  starting
+	//    +0.015s INFO  config: loaded
+	//    +1.250s INFO  connected
+}
+
+func ExampleNewText_timeSinceOrigin() {
+	clock := testutil.NewFakeClock(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	ctx := testutil.WithClock(context.Background(), clock)
+	ctx = clog.WithLogger(ctx, slog.New(handler.NewText(handler.TimeSinceOrigin(), handler.EnabledLevel(slog.LevelInfo))))
+
+	ctx = clog.WithTimeOrigin(ctx, clock.Now())
+	clock.Advance(300 * time.Millisecond)
+	clog.Info(ctx, "step one")
+	clock.Advance(2 * time.Second)
+	clog.Info(ctx, "step two")
+
+	// Output:
+	//    +0.300s INFO  step one
+	//    +2.300s INFO  step two
+}
+
+func ExampleNewText_timeLocation() {
+	clock := testutil.NewFakeClock(time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600)))
+	ctx := testutil.WithClock(context.Background(), clock)
+	ctx = clog.WithLogger(ctx, slog.New(handler.NewText(handler.TimeLocation(time.UTC), handler.EnabledLevel(slog.LevelInfo))))
+
+	clog.Info(ctx, "in UTC")
+
+	// Output:
+	// 2026-01-02T02:04:05.000 INFO  in UTC
+}
+
 func ExampleNewText_levelEnabler() {
 	type lvlKey struct{}
 	ctx := clog.WithTreeLevel(context.Background(), slog.LevelInfo)
@@ -103,3 +153,334 @@ func ExampleInfof_withAttrsAndGroups() {
 	// 03:04:05.6789 INFO  group: Hello, world! value=2.24
 	// 03:04:05.6789 INFO  group: Hello, world! value: 2.240
 }
+
+func ExampleNewFlightRecorder() {
+	h := handler.NewText(handler.TimeFormat(""), handler.LevelEnabler(clog.TreeEnabled))
+	lg := slog.New(handler.NewFlightRecorder(h, handler.RecordLevel(slog.LevelDebug)))
+	ctx := clog.WithLogger(context.Background(), lg)
+
+	// Each request gets a tree of its own, and hence a flight recorder ring of its own.
+	req1 := clog.WithTreeLevel(clog.With(ctx, "req", 1), slog.LevelInfo)
+	req2 := clog.WithTreeLevel(clog.With(ctx, "req", 2), slog.LevelInfo)
+
+	clog.Info(req1, "request received")
+	clog.Debug(req1, "cache miss")
+	clog.Debug(req2, "cache hit")
+	clog.Debugf(req1, "querying %s", "database")
+	clog.Error(req1, "query failed")
+	clog.Info(req2, "request done")
+
+	// Output:
+	// INFO  request received : req=1
+	// DEBUG cache miss : req=1
+	// DEBUG querying database : req=1
+	// ERROR query failed : req=1
+	// INFO  request done : req=2
+}
+
+func ExampleRedirectStdLog() {
+	lg := slog.New(handler.NewText(handler.TimeFormat(""), handler.EnabledLevel(slog.LevelDebug)))
+	ctx := clog.WithLogger(context.Background(), lg)
+
+	restore := clog.RedirectStdLog(clog.WithGroup(ctx, "lib"), slog.LevelInfo, clog.LevelPrefixes())
+	defer restore()
+
+	stdLog.Printf("connecting to %s", "example.com")
+	stdLog.Print("[WARN] retrying")
+	stdLog.Println("debug: using cached credentials")
+
+	// Output:
+	// INFO  lib: connecting to example.com
+	// WARN  lib: retrying
+	// DEBUG lib: using cached credentials
+}
+
+func ExampleLineWriter() {
+	lg := slog.New(handler.NewText(handler.TimeFormat(""), handler.EnabledLevel(slog.LevelInfo)))
+	ctx := clog.WithLogger(context.Background(), lg)
+
+	w := clog.LineWriter(ctx, slog.LevelInfo, clog.LineAttrs(slog.String("stream", "stderr")), clog.LineClassifier(clog.SplitLevelPrefix))
+	_, _ = w.Write([]byte("pulling ima"))
+	_, _ = w.Write([]byte("ge\r\n\nERROR: pull failed\r\nincomplete"))
+	_ = w.Close()
+
+	// Output:
+	// INFO  pulling image : stream=stderr
+	// ERROR pull failed : stream=stderr
+	// INFO  incomplete : stream=stderr
+}
+
+func ExampleCommand() {
+	lg := slog.New(handler.NewText(handler.TimeFormat(""), handler.EnabledLevel(slog.LevelDebug)))
+	ctx := clog.WithLogger(context.Background(), lg)
+	ctx = testutil.WithClock(ctx, testutil.NewFakeClock(time.Now()))
+
+	// The test binary acts as a program that prints "connected" and exits with status 3.
+	cmd := clog.Command(ctx, os.Args[0], "--password=s3cret", "postgres://admin:s3cret@db/app")
+	cmd.Args[0] = "clog.test" // Log a stable name instead of the path of the test binary.
+	cmd.Env = append(os.Environ(), helperEnv+"=exit3")
+	err := cmd.Run()
+	fmt.Println(err)
+
+	// Output:
+	// DEBUG clog.test: running clog.test --password=*** postgres://admin:***@db/app
+	// DEBUG clog.test: connected : stream=stdout
+	// DEBUG clog.test: exited : status=3 duration=0s
+	// exit status 3
+}
+
+func ExampleStart() {
+	lg := slog.New(handler.NewText(handler.TimeFormat(""), handler.EnabledLevel(slog.LevelDebug)))
+	clock := testutil.NewFakeClock(time.Now())
+	ctx := testutil.WithClock(clog.WithLogger(context.Background(), lg), clock)
+
+	connect := func(ctx context.Context, host string) (err error) {
+		ctx, done := clog.Start(ctx, "connect", slog.String("host", host))
+		defer done(&err)
+
+		resolve := func(ctx context.Context) (err error) {
+			ctx, done := clog.Start(ctx, "resolve")
+			defer done(&err)
+			clock.Advance(12 * time.Millisecond)
+			return nil
+		}
+		if err = resolve(ctx); err != nil {
+			return err
+		}
+		clock.Advance(time.Second)
+		return errors.New("connection refused")
+	}
+	_ = connect(ctx, "example.com")
+
+	// Output:
+	// DEBUG connect: started : host=example.com
+	// DEBUG connect/resolve: started
+	// DEBUG connect/resolve: done : duration=12ms
+	// ERROR connect: failed : duration=1.012s error="connection refused"
+}
+
+func ExampleLazy() {
+	lg := slog.New(handler.NewFanout(
+		handler.NewText(handler.TimeFormat(""), handler.EnabledLevel(slog.LevelDebug)),
+		handler.NewText(handler.TimeFormat(""), handler.EnabledLevel(slog.LevelDebug), handler.HideLevel(slog.LevelDebug))))
+	ctx := clog.WithLogger(context.Background(), lg)
+
+	calls := 0
+	dumpPods := func() any {
+		calls++
+		return "pod-1,pod-2"
+	}
+	clog.Trace(ctx, "pods", clog.Lazy("pods", dumpPods))
+	clog.Debug(ctx, "pods", clog.Lazy("pods", dumpPods))
+	clog.Debug(ctx, "pod", clog.LazyGroup("pod", func() []slog.Attr {
+		calls++
+		return []slog.Attr{slog.String("name", "pod-1"), slog.String("phase", "Running")}
+	}))
+	fmt.Println("calls:", calls)
+
+	// Output:
+	// DEBUG pods : pods=pod-1,pod-2
+	// pods : pods=pod-1,pod-2
+	// DEBUG pod: pod : name=pod-1 phase=Running
+	// pod: pod : name=pod-1 phase=Running
+	// calls: 2
+}
+
+func ExampleExtractFormatAttrs() {
+	noTime := func(_ []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return a
+	}
+	lg := slog.New(handler.ExtractFormatAttrs(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{ReplaceAttr: noTime})))
+	ctx := clog.WithLogger(context.Background(), lg)
+
+	clog.Infof(ctx, "Hello, world! %s", slog.Float64("value", 2.24))
+
+	lg = slog.New(handler.ExtractFormatAttrs(handler.NewText(handler.TimeFormat(""), handler.EnabledLevel(slog.LevelInfo))))
+	ctx = clog.WithLogger(context.Background(), lg)
+
+	clog.Infof(ctx, "Hello, world! %s", slog.Float64("value", 2.24))
+
+	// Output:
+	// {"level":"INFO","msg":"Hello, world! value=2.24","value":2.24}
+	// INFO  Hello, world! value=2.24 : value=2.24
+}
+
+func ExamplePreserveTemplate() {
+	noTime := func(_ []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return a
+	}
+	lg := slog.New(handler.PreserveTemplate(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{ReplaceAttr: noTime})))
+	ctx := clog.WithLogger(context.Background(), lg)
+
+	clog.Errorf(ctx, "session %s expired after %v", "4f3a9c", 30*time.Minute)
+
+	lg = slog.New(handler.PreserveTemplate(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{ReplaceAttr: noTime})))
+	ctx = clog.WithLogger(context.Background(), lg)
+
+	clog.Errorf(ctx, "session %s expired after %v", "4f3a9c", 30*time.Minute)
+
+	// Output:
+	// level=ERROR msg="session 4f3a9c expired after 30m0s" msg_template="session %s expired after %v" args.0=4f3a9c args.1=30m0s
+	// {"level":"ERROR","msg":"session 4f3a9c expired after 30m0s","msg_template":"session %s expired after %v","args":{"0":"4f3a9c","1":1800000000000}}
+}
+
+func ExampleNewECS() {
+	lg := slog.New(handler.NewECS(os.Stdout, &slog.HandlerOptions{Level: clog.LevelTrace}))
+	ctx := clog.WithLogger(context.Background(), lg)
+	ctx = testutil.WithClock(ctx, testutil.NewFakeClock(time.Date(2026, 1, 2, 3, 4, 5, 678900000, time.UTC)))
+
+	clog.Trace(ctx, "dialing", slog.Group("http", "method", "GET"))
+	clog.Error(ctx, "request failed", "err", errors.New("connection refused"))
+
+	// Output:
+	// {"@timestamp":"2026-01-02T03:04:05.6789Z","log.level":"trace","message":"dialing","ecs.version":"8.11.0","http":{"method":"GET"}}
+	// {"@timestamp":"2026-01-02T03:04:05.6789Z","log.level":"error","message":"request failed","ecs.version":"8.11.0","error":{"message":"connection refused","type":"*errors.errorString"}}
+}
+
+func ExampleParseText() {
+	logs := `2026-01-02T03:04:05.678 INFO  server: listening : addr=:8080
+2026-01-02T03:04:06.001 ERROR request failed
+with a second line : method=GET http={status=503 reason="service unavailable"} (from server.go:42)
+2026-01-02T03:04:06.120 TRACE+1 retrying`
+	for r, err := range handler.ParseText(strings.NewReader(logs), handler.ParseLocation(time.UTC)) {
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("%s %s %v %q", r.Time.Format(time.TimeOnly), r.Level, r.Groups, r.Message)
+		r.Attrs(func(a slog.Attr) bool {
+			fmt.Printf(" %s", a)
+			return true
+		})
+		if r.Source != nil {
+			fmt.Printf(" %s:%d", r.Source.File, r.Source.Line)
+		}
+		fmt.Println()
+	}
+	// Output:
+	// 03:04:05 INFO [server] "listening" addr=:8080
+	// 03:04:06 ERROR [] "request failed\nwith a second line" method=GET http=[status=503 reason=service unavailable] server.go:42
+	// 03:04:06 DEBUG-3 [] "retrying"
+}
+
+func ExampleMerge() {
+	daemon := `2026-01-02T03:04:05.000 INFO  connecting
+2026-01-02T03:04:05.300 INFO  connected`
+	// The agent runs in another time zone, and its clock is half a second ahead.
+	agent := `2026-01-02T04:04:05.350 INFO  accepting
+2026-01-02T04:04:05.600 INFO  accepted`
+	cet := time.FixedZone("CET", 3600)
+	records := handler.Merge(
+		handler.MergeInput{
+			Name:    "daemon",
+			Records: handler.ParseText(strings.NewReader(daemon), handler.ParseLocation(time.UTC)),
+		},
+		handler.MergeInput{
+			Name:    "agent",
+			Records: handler.ParseText(strings.NewReader(agent), handler.ParseLocation(cet)),
+			Offset:  -500 * time.Millisecond,
+		},
+	)
+	for r, err := range records {
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("%-6s %s %s\n", r.Origin, r.Time.UTC().Format("15:04:05.000"), r.Message)
+	}
+	// Output:
+	// agent  03:04:04.850 accepting
+	// daemon 03:04:05.000 connecting
+	// agent  03:04:05.100 accepted
+	// daemon 03:04:05.300 connected
+}
+
+func ExampleRegisterLevel() {
+	const LevelAudit = slog.LevelError + 8
+	defer internal.ResetLevels() // Don't let the level leak into other examples.
+	if err := clog.RegisterLevel("AUDIT", LevelAudit); err != nil {
+		fmt.Println(err)
+		return
+	}
+	lg := slog.New(handler.NewText(handler.TimeFormat(""), handler.EnabledLevel(slog.LevelInfo)))
+	ctx := clog.WithLogger(context.Background(), lg)
+	clog.Log(ctx, LevelAudit, "user logged in", "user", "jane")
+	clog.Log(ctx, LevelAudit+1, "above audit")
+
+	lg = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return handler.ReplaceLevelName(groups, a)
+	}}))
+	lg.Log(context.Background(), LevelAudit, "user logged out")
+
+	l, _ := clog.ParseLevel("audit")
+	fmt.Println(l == LevelAudit, clog.LevelStrings())
+
+	// Renaming a built-in level changes how it's written, but its built-in name is still parsed.
+	_ = clog.RegisterLevel("WARNING", slog.LevelWarn)
+	l, _ = clog.ParseLevel("warn")
+	fmt.Println(l == slog.LevelWarn, clog.LevelStrings())
+
+	// Output:
+	// AUDIT user logged in : user=jane
+	// AUDIT+1 above audit
+	// {"level":"AUDIT","msg":"user logged out"}
+	// true [AUDIT ERROR WARN INFO DEBUG TRACE]
+	// true [AUDIT ERROR WARNING INFO DEBUG TRACE]
+}
+
+func ExampleFatal() {
+	// Write LevelFatal as "FATAL" instead of "ERROR+4".
+	defer internal.ResetLevels()
+	_ = clog.RegisterLevel("FATAL", clog.LevelFatal)
+
+	lg := slog.New(handler.NewText(handler.TimeFormat(""), handler.EnabledLevel(slog.LevelInfo)))
+	ctx := clog.WithLogger(context.Background(), lg)
+	ctx = clog.WithExitFunc(ctx, func(code int) {
+		fmt.Println("exit", code)
+	})
+
+	clog.Fatalf(ctx, "unable to start: %v", errors.New("address in use"))
+
+	// Output:
+	// FATAL unable to start: address in use
+	// exit 1
+}
+
+func ExamplePanic() {
+	lg := slog.New(handler.NewText(handler.TimeFormat(""), handler.EnabledLevel(slog.LevelInfo)))
+	ctx := clog.WithLogger(context.Background(), lg)
+
+	defer func() {
+		fmt.Println("recovered:", recover())
+	}()
+	clog.Panic(ctx, "inconsistent state", "id", 42)
+
+	// Output:
+	// ERROR+2 inconsistent state : id=42
+	// recovered: inconsistent state
+}
+
+func ExampleFlush() {
+	out := bufio.NewWriter(os.Stdout)
+	lg := slog.New(handler.NewText(handler.Output(out), handler.TimeFormat(""), handler.EnabledLevel(slog.LevelInfo)))
+	ctx := clog.WithLogger(context.Background(), lg)
+
+	clog.Info(ctx, "buffered")
+	fmt.Println("before flush")
+	if err := clog.Flush(ctx); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// before flush
+	// INFO  buffered
+}
@@ -0,0 +1,258 @@
+package clog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/telepresenceio/clog/internal"
+)
+
+// Cmd is an [os/exec.Cmd] that logs its command line, its output, and its exit status on the context logger.
+// All records are logged in a group named after the program.
+type Cmd struct {
+	*exec.Cmd
+
+	// StdoutLevel is the level used when logging lines written to stdout. The default is [slog.LevelDebug].
+	StdoutLevel slog.Level
+
+	// StderrLevel is the level used when logging lines written to stderr. The default is [slog.LevelDebug].
+	StderrLevel slog.Level
+
+	// Redact is used when logging the command line. The default is [RedactArgs].
+	Redact func(args []string) []string
+
+	ctx     context.Context
+	start   time.Time
+	writers []io.Closer
+
+	// stdoutPiped and stderrPiped are set when the stream is logged by the reader returned by StdoutPipe or StderrPipe.
+	stdoutPiped bool
+	stderrPiped bool
+}
+
+// Command is like [os/exec.CommandContext], but returns a [Cmd] that logs on the context logger.
+func Command(ctx context.Context, name string, args ...string) *Cmd {
+	program := strings.TrimSuffix(filepath.Base(name), ".exe")
+	return &Cmd{
+		Cmd:         exec.CommandContext(ctx, name, args...),
+		StdoutLevel: slog.LevelDebug,
+		StderrLevel: slog.LevelDebug,
+		Redact:      RedactArgs,
+		ctx:         WithGroup(ctx, program),
+	}
+}
+
+// Start logs the command line at [slog.LevelDebug] and starts the command. The output of the command is
+// logged line by line, in addition to being written to the Stdout and Stderr writers of the command if
+// they are set.
+func (c *Cmd) Start() error {
+	if internal.Logger(c.ctx).Enabled(c.ctx, slog.LevelDebug) {
+		internal.LogAttrs(c.ctx, slog.LevelDebug, "running "+c.commandLine())
+	}
+	if !c.stdoutPiped {
+		c.Stdout = c.teeLines(c.Stdout, c.StdoutLevel, "stdout")
+	}
+	if !c.stderrPiped {
+		c.Stderr = c.teeLines(c.Stderr, c.StderrLevel, "stderr")
+	}
+	c.start = internal.Now(c.ctx)
+	err := c.Cmd.Start()
+	if err != nil {
+		c.closeWriters()
+		internal.LogAttrs(c.ctx, slog.LevelDebug, "failed to start", slog.Any("error", err))
+	}
+	return err
+}
+
+// Wait waits for the command to exit, and logs its exit status and the duration of its execution at [slog.LevelDebug].
+func (c *Cmd) Wait() error {
+	err := c.Cmd.Wait()
+	c.closeWriters()
+	attrs := make([]slog.Attr, 0, 3)
+	if c.ProcessState != nil {
+		attrs = append(attrs, slog.Int("status", c.ProcessState.ExitCode()))
+	}
+	attrs = append(attrs, slog.Duration("duration", internal.Now(c.ctx).Sub(c.start)))
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	internal.LogAttrs(c.ctx, slog.LevelDebug, "exited", attrs...)
+	return err
+}
+
+// Run starts the command and waits for it to complete.
+func (c *Cmd) Run() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+// Output runs the command and returns its standard output, which is also logged.
+func (c *Cmd) Output() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	var buf bytes.Buffer
+	c.Stdout = &buf
+	err := c.Run()
+	return buf.Bytes(), err
+}
+
+// CombinedOutput runs the command and returns its combined standard output and standard error, which
+// are also logged.
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	if c.Stderr != nil {
+		return nil, errors.New("exec: Stderr already set")
+	}
+	var buf bytes.Buffer
+	w := &syncWriter{w: &buf}
+	c.Stdout = w
+	c.Stderr = w
+	err := c.Run()
+	return buf.Bytes(), err
+}
+
+// StdoutPipe is like [os/exec.Cmd.StdoutPipe]. The output is logged line by line as it is read from the pipe.
+func (c *Cmd) StdoutPipe() (io.ReadCloser, error) {
+	r, err := c.Cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	c.stdoutPiped = true
+	return c.teePipe(r, c.StdoutLevel, "stdout"), nil
+}
+
+// StderrPipe is like [os/exec.Cmd.StderrPipe]. The output is logged line by line as it is read from the pipe.
+func (c *Cmd) StderrPipe() (io.ReadCloser, error) {
+	r, err := c.Cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	c.stderrPiped = true
+	return c.teePipe(r, c.StderrLevel, "stderr"), nil
+}
+
+// teePipe returns a reader that logs what's read from the pipe.
+func (c *Cmd) teePipe(r io.ReadCloser, level slog.Level, stream string) io.ReadCloser {
+	lw := LineWriter(c.ctx, level, LineAttrs(slog.String("stream", stream)))
+	c.writers = append(c.writers, lw)
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.TeeReader(r, lw), r}
+}
+
+func (c *Cmd) teeLines(w io.Writer, level slog.Level, stream string) io.Writer {
+	lw := LineWriter(c.ctx, level, LineAttrs(slog.String("stream", stream)))
+	c.writers = append(c.writers, lw)
+	if w == nil {
+		return lw
+	}
+	return io.MultiWriter(w, lw)
+}
+
+func (c *Cmd) closeWriters() {
+	for _, w := range c.writers {
+		_ = w.Close()
+	}
+	c.writers = nil
+}
+
+func (c *Cmd) commandLine() string {
+	args := c.Args
+	if c.Redact != nil && len(args) > 1 {
+		args = append(args[:1:1], c.Redact(args[1:])...)
+	}
+	var sb strings.Builder
+	for i, arg := range args {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\") {
+			arg = strconv.Quote(arg)
+		}
+		sb.WriteString(arg)
+	}
+	return sb.String()
+}
+
+var secretName = regexp.MustCompile(`(?i)(pass(word|wd)?|secret|token|api[-_]?key|credentials?)$`)
+
+const redacted = "***"
+
+// RedactArgs returns a copy of args where secrets are replaced by "***". A secret is the value of a --flag=value
+// or NAME=value argument where the name ends with "password", "passwd", "secret", "token", "apikey", or
+// "credentials", using case-insensitive comparison. The password of URLs with user information is also redacted.
+//
+// An argument that follows a flag isn't redacted, because the flag may be a boolean flag, like --use-token.
+// Use [RedactFlags] to redact the values of flags that take the next argument as their value.
+func RedactArgs(args []string) []string {
+	return redact(args, nil)
+}
+
+// RedactFlags returns a function, suitable as the Redact field of a [Cmd], that redacts like [RedactArgs], and
+// also redacts the argument that follows any of the given flags, e.g. "--password" or "-p".
+func RedactFlags(flags ...string) func(args []string) []string {
+	return func(args []string) []string {
+		return redact(args, flags)
+	}
+}
+
+func redact(args, valueFlags []string) []string {
+	out := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		out[i] = arg
+		if redactNext {
+			out[i] = redacted
+			redactNext = false
+			continue
+		}
+		if slices.Contains(valueFlags, arg) {
+			redactNext = true
+			continue
+		}
+		name, _, hasValue := strings.Cut(arg, "=")
+		if hasValue && secretName.MatchString(strings.TrimLeft(name, "-")) {
+			out[i] = name + "=" + redacted
+			continue
+		}
+		if u, err := url.Parse(arg); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				// Not url.UserPassword, which would escape the marker.
+				u.User = url.User(u.User.Username())
+				user := u.User.String()
+				out[i] = strings.Replace(u.String(), user+"@", user+":"+redacted+"@", 1)
+			}
+		}
+	}
+	return out
+}
+
+type syncWriter struct {
+	sync.Mutex
+	w io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.w.Write(p)
+}
@@ -0,0 +1,71 @@
+package clog_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/telepresenceio/clog"
+	"github.com/telepresenceio/clog/handler"
+)
+
+func TestCmd_StdoutPipe(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := clog.WithLogger(context.Background(), slog.New(handler.NewText(handler.Output(&buf), handler.TimeFormat(""), handler.EnabledLevel(slog.LevelDebug))))
+	cmd := clog.Command(ctx, os.Args[0])
+	cmd.Env = append(os.Environ(), helperEnv+"=exit3")
+	r, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "connected\n" {
+		t.Errorf("read %q from the pipe", got)
+	}
+	if err = cmd.Wait(); err == nil || err.Error() != "exit status 3" {
+		t.Errorf("unexpected Wait error %v", err)
+	}
+	if !strings.Contains(buf.String(), "connected : stream=stdout\n") {
+		t.Errorf("the output wasn't logged:\n%s", buf.String())
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	tests := []struct {
+		redact func([]string) []string
+		args   []string
+		want   []string
+	}{
+		{
+			clog.RedactArgs,
+			[]string{"--use-token", "build", "--password=s3cret", "API_KEY=k", "--token", "t"},
+			[]string{"--use-token", "build", "--password=***", "API_KEY=***", "--token", "t"},
+		},
+		{
+			clog.RedactArgs,
+			[]string{"postgres://admin:s3cret@db/app", "https://example.com"},
+			[]string{"postgres://admin:***@db/app", "https://example.com"},
+		},
+		{
+			clog.RedactFlags("--token", "-p"),
+			[]string{"--use-token", "build", "--token", "t", "-p", "s3cret", "--secret=s"},
+			[]string{"--use-token", "build", "--token", "***", "-p", "***", "--secret=***"},
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.redact(tt.args); !slices.Equal(got, tt.want) {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
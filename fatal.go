@@ -0,0 +1,67 @@
+package clog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/telepresenceio/clog/internal"
+)
+
+// LevelPanic is the level used by [Panic], [Panicf], and [PanicAttrs]. It has no name of its own, so it's
+// written as "ERROR+2" unless the application names it, e.g. using RegisterLevel("PANIC", LevelPanic).
+const LevelPanic = slog.LevelError + 2
+
+// LevelFatal is the level used by [Fatal], [Fatalf], and [FatalAttrs]. It has no name of its own, so it's
+// written as "ERROR+4" unless the application names it, e.g. using RegisterLevel("FATAL", LevelFatal).
+const LevelFatal = slog.LevelError + 4
+
+// WithExitFunc assigns the function that is called by [Fatal], [Fatalf], and [FatalAttrs] to a child
+// context which is returned. The default is [os.Exit]. Tests can use it to verify that a fatal error
+// is logged without terminating the test binary.
+func WithExitFunc(ctx context.Context, exit func(code int)) context.Context {
+	return internal.WithExitFunc(ctx, exit)
+}
+
+// Fatal is similar to [Error], but logs at [LevelFatal], flushes the handlers of the context logger,
+// and then calls the exit function of the context with exit code 1. See [WithExitFunc].
+func Fatal(ctx context.Context, args ...any) {
+	internal.Log(ctx, LevelFatal, args...)
+	internal.Fatal(ctx)
+}
+
+// FatalAttrs is similar to [ErrorAttrs], but logs at [LevelFatal], flushes the handlers of the context
+// logger, and then calls the exit function of the context with exit code 1. See [WithExitFunc].
+func FatalAttrs(ctx context.Context, message string, attrs ...slog.Attr) {
+	internal.LogAttrs(ctx, LevelFatal, message, attrs...)
+	internal.Fatal(ctx)
+}
+
+// Fatalf is similar to [Errorf], but logs at [LevelFatal], flushes the handlers of the context logger,
+// and then calls the exit function of the context with exit code 1. See [WithExitFunc].
+func Fatalf(ctx context.Context, format string, args ...any) {
+	internal.Logf(ctx, LevelFatal, format, args...)
+	internal.Fatal(ctx)
+}
+
+// Panic is similar to [Error], but logs at [LevelPanic], flushes the handlers of the context logger,
+// and then panics with the message.
+func Panic(ctx context.Context, args ...any) {
+	internal.Log(ctx, LevelPanic, args...)
+	internal.Panic(ctx, internal.PanicMessage(args))
+}
+
+// PanicAttrs is similar to [ErrorAttrs], but logs at [LevelPanic], flushes the handlers of the context
+// logger, and then panics with the message.
+func PanicAttrs(ctx context.Context, message string, attrs ...slog.Attr) {
+	internal.LogAttrs(ctx, LevelPanic, message, attrs...)
+	internal.Panic(ctx, message)
+}
+
+// Panicf is similar to [Errorf], but logs at [LevelPanic], flushes the handlers of the context logger,
+// and then panics with the formatted message.
+func Panicf(ctx context.Context, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	internal.LogAttrs(ctx, LevelPanic, msg)
+	internal.Panic(ctx, msg)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/telepresenceio/clog/internal"
+)
+
+// ECSVersion is the version of the Elastic Common Schema that [NewECS] conforms to.
+const ECSVersion = "8.11.0"
+
+// NewECS creates a new [slog.JSONHandler] that writes records using the field names of the Elastic Common
+// Schema (ECS), so that the output can be indexed into Elasticsearch without ingest pipelines that rearrange
+// the fields:
+//
+//   - The time is written as "@timestamp", the level as "log.level", and the message as "message".
+//   - The source, when enabled using opts.AddSource, is written as "log.origin.file.name",
+//     "log.origin.file.line", and "log.origin.function".
+//   - Top level attributes with error values are written as "error.message", "error.type", and,
+//     when formatting the error with "%+v" adds information, "error.stack_trace".
+//   - Groups are written as nested objects, and each record has an "ecs.version" field.
+//
+// The opts.ReplaceAttr function, if any, is called before the ECS field names are applied.
+func NewECS(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+	var o slog.HandlerOptions
+	if opts != nil {
+		o = *opts
+	}
+	replace := o.ReplaceAttr
+	o.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if replace != nil {
+			a = replace(groups, a)
+		}
+		if len(groups) > 0 {
+			return a
+		}
+		return ecsAttr(a)
+	}
+	return slog.NewJSONHandler(w, &o).WithAttrs([]slog.Attr{slog.String("ecs.version", ECSVersion)})
+}
+
+func ecsAttr(a slog.Attr) slog.Attr {
+	switch a.Key {
+	case slog.TimeKey:
+		if a.Value.Kind() == slog.KindTime {
+			a.Key = "@timestamp"
+		}
+		return a
+	case slog.LevelKey:
+		if l, ok := a.Value.Any().(slog.Level); ok {
+			return slog.String("log.level", strings.ToLower(internal.LevelName(l)))
+		}
+		return a
+	case slog.MessageKey:
+		a.Key = "message"
+		return a
+	case slog.SourceKey:
+		if src, ok := a.Value.Any().(*slog.Source); ok {
+			return slog.Group("log",
+				slog.Group("origin",
+					slog.Group("file", slog.String("name", src.File), slog.Int("line", src.Line)),
+					slog.String("function", src.Function)))
+		}
+		return a
+	}
+	if a.Value.Kind() == slog.KindAny {
+		if err, ok := a.Value.Any().(error); ok {
+			msg := err.Error()
+			attrs := []slog.Attr{slog.String("message", msg), slog.String("type", fmt.Sprintf("%T", err))}
+			if st := fmt.Sprintf("%+v", err); st != msg {
+				attrs = append(attrs, slog.String("stack_trace", st))
+			}
+			return slog.GroupAttrs("error", attrs...)
+		}
+	}
+	return a
+}
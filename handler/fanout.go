@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// NewFanout creates a new slog.Handler that passes each record to all the given handlers that are enabled
+// for the level of the record. Attribute values implementing [slog.LogValuer] are resolved once, before the
+// record is passed on.
+func NewFanout(handlers ...slog.Handler) slog.Handler {
+	return fanout(handlers)
+}
+
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, record slog.Record) error {
+	resolved := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		a.Value = a.Value.Resolve()
+		resolved.AddAttrs(a)
+		return true
+	})
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, record.Level) {
+			if err := h.Handle(ctx, resolved.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Unwrap returns the handlers that each record is distributed to.
+func (f fanout) Unwrap() []slog.Handler {
+	return f
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	f2 := make(fanout, len(f))
+	for i, h := range f {
+		f2[i] = h.WithAttrs(attrs)
+	}
+	return f2
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	f2 := make(fanout, len(f))
+	for i, h := range f {
+		f2[i] = h.WithGroup(name)
+	}
+	return f2
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/telepresenceio/clog/internal"
+)
+
+// Flusher is implemented by handlers that buffer records or output, such as the handler of the otlp package,
+// and the text handler when it writes to a writer with a Flush() error method, like a [bufio.Writer].
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Closer is implemented by handlers that hold resources, such as connections or files, that must be released
+// when the handler is no longer used. A closed handler must not be used. Handlers that buffer output must
+// write it, or discard it, when they are closed.
+type Closer interface {
+	Close() error
+}
+
+// ContextCloser is implemented by handlers that can take long to close, such as the handler of the otlp
+// package, which exports its queued records when it's closed. CloseContext gives up when ctx is done.
+type ContextCloser interface {
+	CloseContext(ctx context.Context) error
+}
+
+// Flush flushes h and the handlers that it wraps, outermost first, so that output buffered by a wrapper
+// reaches the handlers that it wraps before they are flushed. A handler wraps other handlers if it has an
+// Unwrap() slog.Handler or an Unwrap() []slog.Handler method, like the handlers of this package that wrap
+// other handlers. Handlers that don't implement [Flusher] are skipped.
+func Flush(ctx context.Context, h slog.Handler) error {
+	return internal.Flush(ctx, h)
+}
+
+// Close flushes h and the handlers that it wraps, and then closes them, outermost first. See [Flush] for how
+// wrapped handlers are found. Handlers that implement [ContextCloser] are closed using ctx, so that ctx limits
+// the time spent closing them. Other handlers are closed using [Closer], or skipped if they don't implement it.
+// The handlers are closed before Close returns, also when the flushing fails, e.g. because ctx is done.
+func Close(ctx context.Context, h slog.Handler) error {
+	return internal.Close(ctx, h)
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+)
+
+type flushRecorder struct {
+	slog.Handler
+	name    string
+	flushed *[]string
+	err     error
+}
+
+func (f *flushRecorder) Flush(context.Context) error {
+	*f.flushed = append(*f.flushed, f.name)
+	return f.err
+}
+
+func TestFlush(t *testing.T) {
+	var flushed []string
+	errB := errors.New("b failed")
+	a := &flushRecorder{Handler: NewText(), name: "a", flushed: &flushed}
+	b := &flushRecorder{Handler: NewText(), name: "b", flushed: &flushed, err: errB}
+	h := PreserveTemplate(NewFanout(NewFlightRecorder(a), ExtractFormatAttrs(b)))
+
+	if err := Flush(context.Background(), h); !errors.Is(err, errB) {
+		t.Errorf("expected error %v, got %v", errB, err)
+	}
+	if len(flushed) != 2 || flushed[0] != "a" || flushed[1] != "b" {
+		t.Errorf("unexpected flush order %v", flushed)
+	}
+}
+
+type closeRecorder struct {
+	flushRecorder
+}
+
+func (c *closeRecorder) Close() error {
+	*c.flushed = append(*c.flushed, "close "+c.name)
+	return nil
+}
+
+func TestClose(t *testing.T) {
+	var events []string
+	a := &closeRecorder{flushRecorder: flushRecorder{Handler: NewText(), name: "a", flushed: &events}}
+	b := &closeRecorder{flushRecorder: flushRecorder{Handler: NewText(), name: "b", flushed: &events}}
+	if err := Close(context.Background(), NewFanout(a, NewFlightRecorder(b))); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a", "b", "close a", "close b"}
+	if !slices.Equal(events, want) {
+		t.Errorf("got %v, want %v", events, want)
+	}
+
+	events = nil
+	c := &closeRecorder{flushRecorder: flushRecorder{Handler: NewText(), name: "c", flushed: &events}}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := Close(ctx, &blockingFlusher{c}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if want := []string{"c", "close c"}; !slices.Equal(events, want) {
+		t.Errorf("got %v, want %v", events, want)
+	}
+}
+
+// blockingFlusher is a wrapper with a Flush that doesn't complete until the context is done.
+type blockingFlusher struct {
+	slog.Handler
+}
+
+func (b *blockingFlusher) Flush(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (b *blockingFlusher) Unwrap() slog.Handler {
+	return b.Handler
+}
+
+type closeBuffer struct {
+	bytes.Buffer
+	closed int
+}
+
+func (c *closeBuffer) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestTextHandler_flushAndClose(t *testing.T) {
+	var out closeBuffer
+	bw := bufio.NewWriter(&out)
+	h := NewText(Output(bw), TimeFormat(""))
+	_ = h.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelError, "buffered", 0))
+	if out.Len() != 0 {
+		t.Fatal("expected the output to be buffered")
+	}
+	if err := Flush(context.Background(), h.WithGroup("g")); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "ERROR buffered\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+
+	h = NewText(Output(&out))
+	if err := Close(context.Background(), h); err != nil || out.closed != 0 {
+		t.Errorf("expected the writer to be left open, got error %v", err)
+	}
+	h = NewText(Output(&out), CloseOutput())
+	for _, d := range []slog.Handler{h, h.WithGroup("g"), h.WithAttrs([]slog.Attr{slog.Int("a", 1)})} {
+		if err := Close(context.Background(), d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out.closed != 1 {
+		t.Errorf("expected the writer to be closed once, got %d", out.closed)
+	}
+}
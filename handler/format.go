@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/telepresenceio/clog/internal"
+)
+
+// handleFormat passes the record and its format arguments on to h. The formatting is deferred to h
+// if it is capable of it. Otherwise, the message is formatted here.
+func handleFormat(ctx context.Context, h slog.Handler, record *slog.Record, fmtArgs []any) error {
+	if fh, ok := h.(internal.FormatHandler); ok {
+		return fh.HandleFormat(ctx, record, fmtArgs)
+	}
+	if len(fmtArgs) > 0 {
+		record.Message = fmt.Sprintf(record.Message, fmtArgs...)
+	}
+	return h.Handle(ctx, *record)
+}
+
+// ExtractFormatAttrs creates a new slog.Handler that adds the [slog.Attr] arguments given to format functions,
+// such as [clog.Infof], as attributes of the record, in addition to having them formatted into the message.
+// The record is then passed on to h. This gives handlers that produce structured output, such as
+// [slog.JSONHandler], access to values that would otherwise only be found in the message.
+func ExtractFormatAttrs(h slog.Handler) slog.Handler {
+	return &extractFormatAttrs{Handler: h}
+}
+
+type extractFormatAttrs struct {
+	slog.Handler
+}
+
+func (h *extractFormatAttrs) HandleFormat(ctx context.Context, record *slog.Record, fmtArgs []any) error {
+	for _, arg := range fmtArgs {
+		if a, ok := arg.(slog.Attr); ok {
+			record.AddAttrs(a)
+		}
+	}
+	return handleFormat(ctx, h.Handler, record, fmtArgs)
+}
+
+// Unwrap returns the handler that receives the records with the extracted attributes.
+func (h *extractFormatAttrs) Unwrap() slog.Handler {
+	return h.Handler
+}
+
+func (h *extractFormatAttrs) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &extractFormatAttrs{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *extractFormatAttrs) WithGroup(name string) slog.Handler {
+	return &extractFormatAttrs{Handler: h.Handler.WithGroup(name)}
+}
+
+// TemplateKey is the key used by [PreserveTemplate] for the format string of a record.
+const TemplateKey = "msg_template"
+
+// ArgsKey is the key of the group used by [PreserveTemplate] for the format arguments of a record.
+const ArgsKey = "args"
+
+// PreserveTemplate creates a new slog.Handler that adds the format string given to format functions, such
+// as [clog.Infof], as an attribute with the key [TemplateKey], and the format arguments as a group with the
+// key [ArgsKey] that holds one attribute per argument, keyed by its position. The record is then passed on
+// to h. This makes it possible to group records by template, even when the formatted messages differ.
+func PreserveTemplate(h slog.Handler) slog.Handler {
+	return &preserveTemplate{Handler: h}
+}
+
+type preserveTemplate struct {
+	slog.Handler
+}
+
+func (h *preserveTemplate) HandleFormat(ctx context.Context, record *slog.Record, fmtArgs []any) error {
+	record.AddAttrs(slog.String(TemplateKey, record.Message))
+	if len(fmtArgs) > 0 {
+		args := make([]slog.Attr, len(fmtArgs))
+		for i, arg := range fmtArgs {
+			var v slog.Value
+			if a, ok := arg.(slog.Attr); ok {
+				v = a.Value
+			} else {
+				v = slog.AnyValue(arg)
+			}
+			args[i] = slog.Attr{Key: strconv.Itoa(i), Value: v}
+		}
+		record.AddAttrs(slog.GroupAttrs(ArgsKey, args...))
+	}
+	return handleFormat(ctx, h.Handler, record, fmtArgs)
+}
+
+// Unwrap returns the handler that receives the records with the template and arguments added.
+func (h *preserveTemplate) Unwrap() slog.Handler {
+	return h.Handler
+}
+
+func (h *preserveTemplate) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &preserveTemplate{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *preserveTemplate) WithGroup(name string) slog.Handler {
+	return &preserveTemplate{Handler: h.Handler.WithGroup(name)}
+}
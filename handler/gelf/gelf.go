@@ -0,0 +1,278 @@
+// Package gelf provides a slog.Handler that sends records to Graylog using the GELF 1.1 format.
+package gelf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/telepresenceio/clog/handler"
+	"github.com/telepresenceio/clog/handler/syslog"
+	"github.com/telepresenceio/clog/internal"
+	"github.com/telepresenceio/clog/internal/netconn"
+)
+
+// Compression is the compression used for messages sent over UDP.
+type Compression int
+
+const (
+	NoCompression Compression = iota
+	Gzip
+	Zlib
+)
+
+type Option func(*config)
+
+// ChunkSize sets the maximum size of the UDP datagrams. Larger messages are chunked. The default is 1420.
+func ChunkSize(size int) Option {
+	return func(c *config) {
+		c.chunkSize = size
+	}
+}
+
+// Compress sets the compression used for messages sent over UDP. The default is [NoCompression]. Messages
+// sent over TCP are never compressed.
+func Compress(compression Compression) Option {
+	return func(c *config) {
+		c.compression = compression
+	}
+}
+
+// EnabledLevel sets the minimum level of the records sent to the GELF input. The default is [slog.LevelInfo].
+func EnabledLevel(level slog.Level) Option {
+	return func(c *config) {
+		c.SetLevel(level)
+	}
+}
+
+// Host sets the host field of the messages. The default is the name reported by [os.Hostname].
+func Host(name string) Option {
+	return func(c *config) {
+		c.host = name
+	}
+}
+
+// LevelEnabler sets the function that determines if the records of a level are sent to the GELF input. It replaces
+// the minimum level set by [EnabledLevel].
+func LevelEnabler(enabler handler.EnabledFunc) Option {
+	return func(c *config) {
+		c.SetEnabler(enabler)
+	}
+}
+
+// Timeout sets the timeout used when dialing the GELF input and when writing a message or a chunk to it. The
+// default is 5 seconds.
+func Timeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+type config struct {
+	host        string
+	chunkSize   int
+	compression Compression
+	timeout     time.Duration
+	internal.LevelConfig
+}
+
+// Handler is a slog.Handler that sends each record as a GELF message.
+type Handler struct {
+	cfg   *config
+	conn  *netconn.Conn
+	attrs internal.AttrState
+}
+
+// maxChunks is the maximum number of chunks that a GELF message can be split into.
+const maxChunks = 128
+
+// chunkHeaderSize is the size of the header of each chunk: magic bytes, message ID, sequence number and count.
+const chunkHeaderSize = 12
+
+// Dial creates a Handler that sends messages to a GELF input at the given address. The network is "udp" or
+// "tcp", or one of their variants. Messages sent over TCP are terminated by a null byte.
+//
+// The message has the fields version, host, short_message, full_message (when the message has more than one
+// line), timestamp, and level, which is the syslog severity of the record level as given by
+// [syslog.Severity]. Each attribute is added as an additional field with a name made from the keys of the
+// attribute and its enclosing groups, joined by '_' and prefixed by '_'. The source of the record, when
+// present, is added as the additional fields _file, _line, and _function.
+func Dial(network, addr string, options ...Option) (*Handler, error) {
+	cfg := &config{
+		chunkSize: 1420,
+		timeout:   netconn.DefaultTimeout,
+	}
+	cfg.host, _ = os.Hostname()
+	for _, opt := range options {
+		opt(cfg)
+	}
+	if cfg.chunkSize <= chunkHeaderSize {
+		return nil, errors.New("gelf: chunk size is too small")
+	}
+	conn, err := netconn.Dial(network, addr, cfg.timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{cfg: cfg, conn: conn}, nil
+}
+
+// Close closes the connection to the GELF input.
+func (h *Handler) Close() error {
+	return h.conn.Close()
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.cfg.Enabled(ctx, level)
+}
+
+// Handle sends the record as a GELF message.
+func (h *Handler) Handle(_ context.Context, record slog.Record) error {
+	data, err := json.Marshal(h.message(&record))
+	if err != nil {
+		return err
+	}
+	if h.conn.IsStream() {
+		_, err = h.conn.Write(append(data, 0))
+		return err
+	}
+	if data, err = compress(data, h.cfg.compression); err != nil {
+		return err
+	}
+	if len(data) <= h.cfg.chunkSize {
+		_, err = h.conn.Write(data)
+		return err
+	}
+	return h.writeChunked(data)
+}
+
+func (h *Handler) message(record *slog.Record) map[string]any {
+	msg := h.attrs.Message(record.Message)
+
+	m := map[string]any{
+		"version": "1.1",
+		"host":    h.cfg.host,
+		"level":   syslog.Severity(record.Level),
+	}
+	if short, _, multiLine := strings.Cut(msg, "\n"); multiLine {
+		m["short_message"] = short
+		m["full_message"] = msg
+	} else {
+		m["short_message"] = msg
+	}
+	if !record.Time.IsZero() {
+		m["timestamp"] = float64(record.Time.UnixMilli()) / 1000
+	}
+	if src := record.Source(); src != nil {
+		m["_file"] = src.File
+		m["_line"] = src.Line
+		m["_function"] = src.Function
+	}
+	h.attrs.Walk(record, func(path []string, v slog.Value) {
+		m[FieldName(path)] = fieldValue(v)
+	})
+	return m
+}
+
+// FieldName returns the name of the additional field for an attribute. The keys of the attribute and its
+// enclosing groups are joined by '_' and prefixed by '_'. Characters other than letters, digits, '_', '.',
+// and '-' are replaced by '_'. The reserved name "_id" becomes "__id".
+func FieldName(path []string) string {
+	b := make([]byte, 0, 32)
+	for _, key := range path {
+		b = append(b, '_')
+		for _, c := range []byte(key) {
+			switch {
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '.', c == '-':
+			default:
+				c = '_'
+			}
+			b = append(b, c)
+		}
+	}
+	if s := string(b); s != "_id" {
+		return s
+	}
+	return "__id"
+}
+
+// fieldValue returns a number for numeric values and a string for all other values, since additional
+// fields can only be strings or numbers.
+func fieldValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindInt64:
+		return v.Int64()
+	case slog.KindUint64:
+		return v.Uint64()
+	case slog.KindFloat64:
+		f := v.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			// Not representable in JSON.
+			return v.String()
+		}
+		return f
+	default:
+		return v.String()
+	}
+}
+
+func compress(data []byte, compression Compression) ([]byte, error) {
+	var w io.WriteCloser
+	var buf bytes.Buffer
+	switch compression {
+	case Gzip:
+		w = gzip.NewWriter(&buf)
+	case Zlib:
+		w = zlib.NewWriter(&buf)
+	default:
+		return data, nil
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (h *Handler) writeChunked(data []byte) error {
+	size := h.cfg.chunkSize - chunkHeaderSize
+	count := (len(data) + size - 1) / size
+	if count > maxChunks {
+		return errors.New("gelf: message is too large")
+	}
+	chunk := make([]byte, chunkHeaderSize, h.cfg.chunkSize)
+	chunk[0], chunk[1] = 0x1e, 0x0f
+	_, _ = rand.Read(chunk[2:10])
+	chunk[11] = byte(count)
+	for i := range count {
+		chunk[10] = byte(i)
+		end := min(len(data), (i+1)*size)
+		if _, err := h.conn.Write(append(chunk[:chunkHeaderSize], data[i*size:end]...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = h.attrs.WithAttrs(attrs)
+	return &h2
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.attrs = h.attrs.WithGroup(name)
+	return &h2
+}
@@ -0,0 +1,181 @@
+package gelf_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/telepresenceio/clog/handler/gelf"
+)
+
+func decode(t *testing.T, data []byte) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("%v: %q", err, data)
+	}
+	return m
+}
+
+func check(t *testing.T, m map[string]any, want map[string]any) {
+	t.Helper()
+	for k, v := range want {
+		if m[k] != v {
+			t.Errorf("field %s = %v (%T), want %v (%T)", k, m[k], m[k], v, v)
+		}
+	}
+}
+
+func TestDial_tcp(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	h, err := gelf.Dial("tcp", l.Addr().String(), gelf.Host("host"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	lg := slog.New(h).With("id", "abc").WithGroup("session")
+	lg.Warn("first line\nsecond line", slog.Group("http", "status", 503, "path", "/api"))
+	lg.Info("done")
+
+	rd := bufio.NewReader(conn)
+	read := func() map[string]any {
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		data, err := rd.ReadBytes(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return decode(t, data[:len(data)-1])
+	}
+	m := read()
+	check(t, m, map[string]any{
+		"version":              "1.1",
+		"host":                 "host",
+		"short_message":        "session: first line",
+		"full_message":         "session: first line\nsecond line",
+		"level":                4.0,
+		"__id":                 "abc",
+		"_session_http_status": 503.0,
+		"_session_http_path":   "/api",
+	})
+	if _, ok := m["timestamp"].(float64); !ok {
+		t.Errorf("missing timestamp: %v", m)
+	}
+	if f, _ := m["_file"].(string); !strings.HasSuffix(f, "gelf_test.go") {
+		t.Errorf("missing source: %v", m)
+	}
+	m = read()
+	check(t, m, map[string]any{"short_message": "session: done", "level": 6.0})
+	if _, ok := m["full_message"]; ok {
+		t.Errorf("unexpected full_message: %v", m)
+	}
+}
+
+func TestDial_udpChunkedGzip(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	h, err := gelf.Dial("udp", pc.LocalAddr().String(), gelf.ChunkSize(100), gelf.Compress(gelf.Gzip))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	// Random-ish data that doesn't compress well, so that the message must be chunked.
+	var sb strings.Builder
+	for i := range 200 {
+		sb.WriteString(time.Duration(i * i * 7919).String())
+	}
+	slog.New(h).Error("big", "data", sb.String())
+
+	chunks := make(map[byte][]byte)
+	var id []byte
+	count := -1
+	for len(chunks) != count {
+		buf := make([]byte, 200)
+		_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n > 100 {
+			t.Fatalf("datagram of size %d exceeds chunk size", n)
+		}
+		if buf[0] != 0x1e || buf[1] != 0x0f {
+			t.Fatalf("not a chunk: %x", buf[:n])
+		}
+		if id == nil {
+			id = buf[2:10]
+		} else if !bytes.Equal(id, buf[2:10]) {
+			t.Fatal("chunks have different message IDs")
+		}
+		count = int(buf[11])
+		chunks[buf[10]] = buf[12:n]
+	}
+	if count < 2 {
+		t.Fatalf("expected several chunks, got %d", count)
+	}
+	var data []byte
+	for i := range count {
+		data = append(data, chunks[byte(i)]...)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err = io.ReadAll(zr); err != nil {
+		t.Fatal(err)
+	}
+	check(t, decode(t, data), map[string]any{"short_message": "big", "level": 3.0, "_data": sb.String()})
+}
+
+func TestDial_nonFiniteFloat(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	h, err := gelf.Dial("tcp", l.Addr().String(), gelf.Host("host"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	slog.New(h).Info("ratio", "nan", math.NaN(), "inf", math.Inf(-1), "finite", 0.5)
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	data, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, decode(t, data[:len(data)-1]), map[string]any{
+		"short_message": "ratio",
+		"_nan":          "NaN",
+		"_inf":          "-Inf",
+		"_finite":       0.5,
+	})
+}
@@ -0,0 +1,215 @@
+// Package journald provides a slog.Handler that sends records to the systemd journal using its native protocol.
+package journald
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/telepresenceio/clog/handler"
+	"github.com/telepresenceio/clog/handler/syslog"
+	"github.com/telepresenceio/clog/internal"
+)
+
+// DefaultSocket is the path of the socket where journald listens for native protocol messages.
+const DefaultSocket = "/run/systemd/journal/socket"
+
+type Option func(*config)
+
+// EnabledLevel sets the minimum level of the records written to the journal. The default is [slog.LevelInfo].
+func EnabledLevel(level slog.Level) Option {
+	return func(c *config) {
+		c.SetLevel(level)
+	}
+}
+
+// Identifier sets the SYSLOG_IDENTIFIER field of the entries. The default is the base name of the executable.
+func Identifier(id string) Option {
+	return func(c *config) {
+		c.identifier = id
+	}
+}
+
+// LevelEnabler sets the function that determines if the records of a level are written to the journal. It replaces
+// the minimum level set by [EnabledLevel].
+func LevelEnabler(enabler handler.EnabledFunc) Option {
+	return func(c *config) {
+		c.SetEnabler(enabler)
+	}
+}
+
+// SocketPath sets the path of the journald socket. The default is [DefaultSocket].
+func SocketPath(path string) Option {
+	return func(c *config) {
+		c.socket = path
+	}
+}
+
+type config struct {
+	socket     string
+	identifier string
+	internal.LevelConfig
+}
+
+// Handler is a slog.Handler that sends each record as a journal entry.
+type Handler struct {
+	cfg    *config
+	conn   *net.UnixConn
+	addr   *net.UnixAddr
+	attrs  internal.AttrState
+	closer *connCloser
+}
+
+// connCloser closes the socket of a handler and the handlers derived from it once.
+type connCloser struct {
+	once sync.Once
+	err  error
+}
+
+// New creates a Handler that sends entries to the journald socket. The entries have the fields MESSAGE,
+// PRIORITY, SYSLOG_IDENTIFIER, and, when the record has a source, CODE_FILE, CODE_LINE, and CODE_FUNC.
+// Each attribute is added as a field with a name made from the keys of the attribute and its enclosing
+// groups, joined by '_' and converted to upper case. Characters that aren't allowed in a field name are
+// replaced by '_'.
+//
+// Entries that are too large to be sent as a datagram are written to a sealed memory file, which is
+// passed to journald as a file descriptor.
+func New(options ...Option) (*Handler, error) {
+	cfg := &config{
+		socket:     DefaultSocket,
+		identifier: filepath.Base(os.Args[0]),
+	}
+	for _, opt := range options {
+		opt(cfg)
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{cfg: cfg, conn: conn, addr: &net.UnixAddr{Name: cfg.socket, Net: "unixgram"}, closer: &connCloser{}}, nil
+}
+
+// Close closes the socket used by the handler and the handlers derived from it. Closing a closed handler has
+// no effect.
+func (h *Handler) Close() error {
+	h.closer.once.Do(func() {
+		h.closer.err = h.conn.Close()
+	})
+	return h.closer.err
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.cfg.Enabled(ctx, level)
+}
+
+// Handle sends the record as a journal entry.
+func (h *Handler) Handle(_ context.Context, record slog.Record) error {
+	data := h.format(&record)
+	_, _, err := h.conn.WriteMsgUnix(data, nil, h.addr)
+	if err != nil && (errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)) {
+		err = h.sendLarge(data)
+	}
+	return err
+}
+
+func (h *Handler) format(record *slog.Record) []byte {
+	b := make([]byte, 0, 256)
+	b = appendField(b, "MESSAGE", h.attrs.Message(record.Message))
+	b = appendField(b, "PRIORITY", strconv.Itoa(syslog.Severity(record.Level)))
+	if h.cfg.identifier != "" {
+		b = appendField(b, "SYSLOG_IDENTIFIER", h.cfg.identifier)
+	}
+	if src := record.Source(); src != nil {
+		b = appendField(b, "CODE_FILE", src.File)
+		b = appendField(b, "CODE_LINE", strconv.Itoa(src.Line))
+		b = appendField(b, "CODE_FUNC", src.Function)
+	}
+	h.attrs.Walk(record, func(path []string, v slog.Value) {
+		b = appendField(b, FieldName(path), v.String())
+	})
+	return b
+}
+
+// reservedFields are the fields that the handler writes itself.
+var reservedFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+// FieldName returns a journal field name made from the keys of an attribute and its enclosing groups. The
+// keys are joined by '_' and converted to upper case, characters other than A-Z, 0-9 and '_' are replaced by
+// '_', leading underscores are removed since they denote trusted fields, a leading digit is prefixed
+// by 'F', and the name is truncated to 64 characters. Names of the fields written by the handler itself,
+// such as MESSAGE and PRIORITY, are prefixed by "ATTR_" so that an attribute can't replace them.
+func FieldName(path []string) string {
+	b := make([]byte, 0, 32)
+	for i, key := range path {
+		if i > 0 {
+			b = append(b, '_')
+		}
+		for j := 0; j < len(key); j++ {
+			c := key[j]
+			switch {
+			case c >= 'a' && c <= 'z':
+				c -= 'a' - 'A'
+			case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			default:
+				c = '_'
+			}
+			b = append(b, c)
+		}
+	}
+	for len(b) > 0 && b[0] == '_' {
+		b = b[1:]
+	}
+	switch {
+	case len(b) == 0:
+		return "FIELD"
+	case b[0] >= '0' && b[0] <= '9':
+		b = append([]byte{'F'}, b...)
+	case reservedFields[string(b)]:
+		b = append([]byte("ATTR_"), b...)
+	}
+	if len(b) > 64 {
+		b = b[:64]
+	}
+	return string(b)
+}
+
+// appendField appends a field using the NAME=value form, or the binary form when the value contains a newline.
+func appendField(b []byte, name, value string) []byte {
+	b = append(b, name...)
+	if strings.IndexByte(value, '\n') < 0 {
+		b = append(b, '=')
+		b = append(b, value...)
+	} else {
+		b = append(b, '\n')
+		b = binary.LittleEndian.AppendUint64(b, uint64(len(value)))
+		b = append(b, value...)
+	}
+	return append(b, '\n')
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = h.attrs.WithAttrs(attrs)
+	return &h2
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.attrs = h.attrs.WithGroup(name)
+	return &h2
+}
@@ -0,0 +1,201 @@
+//go:build linux
+
+package journald_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/telepresenceio/clog/handler/journald"
+)
+
+// fakeJournal listens on a socket in a temporary directory and returns the entries it receives.
+type fakeJournal struct {
+	t    *testing.T
+	conn *net.UnixConn
+	path string
+}
+
+func newFakeJournal(t *testing.T) *fakeJournal {
+	path := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return &fakeJournal{t: t, conn: conn, path: path}
+}
+
+func (j *fakeJournal) read() map[string]string {
+	j.t.Helper()
+	buf := make([]byte, 65536)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_ = j.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, oobn, _, _, err := j.conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		j.t.Fatal(err)
+	}
+	data := buf[:n]
+	if oobn > 0 {
+		msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			j.t.Fatal(err)
+		}
+		fds, err := syscall.ParseUnixRights(&msgs[0])
+		if err != nil {
+			j.t.Fatal(err)
+		}
+		f := os.NewFile(uintptr(fds[0]), "memfd")
+		defer f.Close()
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			j.t.Fatal(err)
+		}
+		if data, err = io.ReadAll(f); err != nil {
+			j.t.Fatal(err)
+		}
+	}
+	return parseEntry(j.t, data)
+}
+
+func parseEntry(t *testing.T, data []byte) map[string]string {
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		i := bytes.IndexAny(data, "=\n")
+		if i < 0 {
+			t.Fatalf("malformed entry %q", data)
+		}
+		name := string(data[:i])
+		if data[i] == '=' {
+			end := bytes.IndexByte(data, '\n')
+			fields[name] = string(data[i+1 : end])
+			data = data[end+1:]
+		} else {
+			size := binary.LittleEndian.Uint64(data[i+1:])
+			start := i + 9
+			fields[name] = string(data[start : start+int(size)])
+			data = data[start+int(size)+1:]
+		}
+	}
+	return fields
+}
+
+func TestHandler(t *testing.T) {
+	j := newFakeJournal(t)
+	h, err := journald.New(journald.SocketPath(j.path), journald.Identifier("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	lg := slog.New(h).With("user.name", "jane").WithGroup("session")
+	lg.Warn("first line\nsecond line", slog.Group("token", "id", 42, "_ttl", time.Minute))
+
+	got := j.read()
+	want := map[string]string{
+		"MESSAGE":            "session: first line\nsecond line",
+		"PRIORITY":           "4",
+		"SYSLOG_IDENTIFIER":  "test",
+		"USER_NAME":          "jane",
+		"SESSION_TOKEN_ID":   "42",
+		"SESSION_TOKEN__TTL": "1m0s",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("field %s = %q, want %q", k, got[k], v)
+		}
+	}
+	if !strings.HasSuffix(got["CODE_FILE"], "journald_test.go") || got["CODE_LINE"] == "" || got["CODE_FUNC"] == "" {
+		t.Errorf("missing or invalid source fields: %v", got)
+	}
+}
+
+func TestHandler_reservedFields(t *testing.T) {
+	j := newFakeJournal(t)
+	h, err := journald.New(journald.SocketPath(j.path), journald.Identifier("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	slog.New(h).Info("hello", "message", "attr", "priority", 0, "code_file", "attr.go")
+
+	got := j.read()
+	want := map[string]string{
+		"MESSAGE":        "hello",
+		"PRIORITY":       "6",
+		"ATTR_MESSAGE":   "attr",
+		"ATTR_PRIORITY":  "0",
+		"ATTR_CODE_FILE": "attr.go",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("field %s = %q, want %q", k, got[k], v)
+		}
+	}
+	if !strings.HasSuffix(got["CODE_FILE"], "journald_test.go") {
+		t.Errorf("got CODE_FILE %q, want the source of the record", got["CODE_FILE"])
+	}
+}
+
+func TestHandler_large(t *testing.T) {
+	j := newFakeJournal(t)
+	h, err := journald.New(journald.SocketPath(j.path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	big := strings.Repeat("x", 4<<20)
+	if err = h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelError, big, 0)); err != nil {
+		t.Fatal(err)
+	}
+	got := j.read()
+	if got["MESSAGE"] != big {
+		t.Errorf("got MESSAGE of length %d, want %d", len(got["MESSAGE"]), len(big))
+	}
+	if got["PRIORITY"] != "3" {
+		t.Errorf("got PRIORITY %s, want 3", got["PRIORITY"])
+	}
+}
+
+func TestHandler_closeTwice(t *testing.T) {
+	j := newFakeJournal(t)
+	h, err := journald.New(journald.SocketPath(j.path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	derived := h.WithAttrs([]slog.Attr{slog.Int("id", 1)}).(*journald.Handler)
+	if err = h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = derived.Close(); err != nil {
+		t.Errorf("closing a derived handler: %v", err)
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	tests := map[string][]string{
+		"USER_NAME":      {"user", "name"},
+		"TRACE_ID":       {"_trace-id"},
+		"F2FA":           {"2fa"},
+		"FIELD":          {"__"},
+		"HTTP_STATUS":    {"http.status"},
+		"ATTR_CODE_LINE": {"code", "line"},
+		"MESSAGE_ID":     {"message_id"},
+	}
+	for want, path := range tests {
+		if got := journald.FieldName(path); got != want {
+			t.Errorf("FieldName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
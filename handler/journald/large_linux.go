@@ -0,0 +1,71 @@
+package journald
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// memfdCreate holds the number of the memfd_create system call, which the syscall package lacks on most platforms.
+var memfdCreate = map[string]uintptr{
+	"386":      356,
+	"amd64":    319,
+	"arm":      385,
+	"arm64":    279,
+	"loong64":  279,
+	"mips":     4354,
+	"mipsle":   4354,
+	"mips64":   5314,
+	"mips64le": 5314,
+	"ppc64":    360,
+	"ppc64le":  360,
+	"riscv64":  279,
+	"s390x":    350,
+}
+
+const (
+	mfdCloexec      = 0x1
+	mfdAllowSealing = 0x2
+	fAddSeals       = 1033
+	sealAll         = 0x1 | 0x2 | 0x4 | 0x8 // F_SEAL_SEAL | F_SEAL_SHRINK | F_SEAL_GROW | F_SEAL_WRITE
+)
+
+// sendLarge writes the data to a sealed memory file, or to an unlinked file in /dev/shm when memfd_create isn't
+// available, and passes the file descriptor to journald.
+func (h *Handler) sendLarge(data []byte) error {
+	f, sealed, err := newMemFile()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if sealed {
+		if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), fAddSeals, sealAll); errno != 0 {
+			return errno
+		}
+	}
+	_, _, err = h.conn.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), h.addr)
+	return err
+}
+
+func newMemFile() (*os.File, bool, error) {
+	if trap, ok := memfdCreate[runtime.GOARCH]; ok {
+		name, err := syscall.BytePtrFromString("journald")
+		if err != nil {
+			return nil, false, err
+		}
+		fd, _, errno := syscall.Syscall(trap, uintptr(unsafe.Pointer(name)), mfdCloexec|mfdAllowSealing, 0)
+		if errno == 0 {
+			return os.NewFile(fd, "journald"), true, nil
+		}
+	}
+	f, err := os.CreateTemp("/dev/shm", "journald-")
+	if err != nil {
+		return nil, false, err
+	}
+	_ = os.Remove(f.Name())
+	return f, false, nil
+}
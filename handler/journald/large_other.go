@@ -0,0 +1,9 @@
+//go:build !linux
+
+package journald
+
+import "errors"
+
+func (h *Handler) sendLarge([]byte) error {
+	return errors.New("entry is too large to be sent as a datagram")
+}
@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"log/slog"
+
+	"github.com/telepresenceio/clog/internal"
+)
+
+// ReplaceLevelName is a function for the ReplaceAttr field of [slog.HandlerOptions] that writes the level of
+// records using the names of the level registry, e.g. "TRACE" instead of "DEBUG-4", and the name of a level
+// registered using [clog.RegisterLevel] instead of an offset from a built-in level. Functions that replace
+// other attributes can call it for all attributes.
+func ReplaceLevelName(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.LevelKey {
+		if l, ok := a.Value.Any().(slog.Level); ok {
+			a.Value = slog.StringValue(internal.LevelName(l))
+		}
+	}
+	return a
+}
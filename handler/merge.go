@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"container/heap"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// MergeInput is a stream of records that is merged with other streams by [Merge].
+type MergeInput struct {
+	// Name identifies the input. It is used as the Origin of its records, and in its errors.
+	Name string
+
+	// Records are the records of the input, in chronological order.
+	Records iter.Seq2[*ParsedRecord, error]
+
+	// Offset is added to the time of each record of the input, to compensate for the skew of the clock
+	// of the process that produced it.
+	Offset time.Duration
+}
+
+// Merge returns an iterator that merges the records of the inputs into one chronologically ordered
+// stream, using a k-way merge. Records that have the same time are ordered by the position of their
+// input. The Origin of each record is set to the Name of its input, and its Time is adjusted by the
+// Offset of its input. A record without a time is considered to have the time of the preceding record
+// of the same input, so that it stays with its neighbours.
+//
+// Text output written using the [RFC3339MillisNoTz] time format lacks a time zone, so the location of
+// each input must be given to [ParseText] using [ParseLocation] when the inputs come from processes that
+// run in different time zones.
+//
+// An error from an input is yielded with the name of the input, after which the iteration may continue
+// with the next record of that input.
+func Merge(inputs ...MergeInput) iter.Seq2[*ParsedRecord, error] {
+	return func(yield func(*ParsedRecord, error) bool) {
+		h := make(mergeHeap, 0, len(inputs))
+		for i, in := range inputs {
+			next, stop := iter.Pull2(in.Records)
+			defer stop()
+			c := &mergeCursor{index: i, input: &inputs[i], next: next}
+			if !c.advance(yield) {
+				return
+			}
+			if c.record != nil {
+				h = append(h, c)
+			}
+		}
+		heap.Init(&h)
+		for len(h) > 0 {
+			c := h[0]
+			if !yield(c.record, nil) || !c.advance(yield) {
+				return
+			}
+			if c.record == nil {
+				heap.Pop(&h)
+			} else {
+				heap.Fix(&h, 0)
+			}
+		}
+	}
+}
+
+type mergeCursor struct {
+	index  int
+	input  *MergeInput
+	next   func() (*ParsedRecord, error, bool)
+	record *ParsedRecord
+	last   time.Time
+}
+
+// advance makes the next record of the input current, or sets it to nil when the input is exhausted. Errors
+// are yielded along the way, and false is returned if yield returns false.
+func (c *mergeCursor) advance(yield func(*ParsedRecord, error) bool) bool {
+	for {
+		r, err, ok := c.next()
+		if !ok {
+			c.record = nil
+			return true
+		}
+		if err != nil {
+			if !yield(nil, fmt.Errorf("%s: %w", c.input.Name, err)) {
+				return false
+			}
+			continue
+		}
+		if r.Time.IsZero() {
+			r.Time = c.last
+		} else {
+			r.Time = r.Time.Add(c.input.Offset)
+			c.last = r.Time
+		}
+		r.Origin = c.input.Name
+		c.record = r
+		return true
+	}
+}
+
+type mergeHeap []*mergeCursor
+
+func (h mergeHeap) Len() int {
+	return len(h)
+}
+
+func (h mergeHeap) Less(i, j int) bool {
+	ti, tj := h[i].record.Time, h[j].record.Time
+	if ti.Equal(tj) {
+		return h[i].index < h[j].index
+	}
+	return ti.Before(tj)
+}
+
+func (h mergeHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *mergeHeap) Push(x any) {
+	*h = append(*h, x.(*mergeCursor))
+}
+
+func (h *mergeHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
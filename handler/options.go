@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"time"
 )
 
 type Option func(*textHandler)
@@ -56,6 +57,13 @@ func IncludeSource(include bool) Option {
 	}
 }
 
+// Color makes the handler write the level using ANSI color escape sequences, for output to a terminal.
+func Color(enable bool) Option {
+	return func(h *textHandler) {
+		h.color = enable
+	}
+}
+
 // LevelOutput sets a writer that is capable of sending output to different locations depending on the log level.
 // LevelOutput is mutually exclusive with Output.
 // The writer must be thread-safe.
@@ -74,6 +82,52 @@ func Output(w io.Writer) Option {
 	}
 }
 
+// CloseOutput makes the handler own the writer given to the Output or LevelOutput option, so that the Close
+// method of the handler closes the writer if it is an [io.Closer]. The writer is closed once, also when Close
+// is called on several handlers derived from the handler using WithAttrs or WithGroup. Without this option,
+// the writer is never closed by the handler.
+func CloseOutput() Option {
+	return func(h *textHandler) {
+		h.closer = &outputCloser{}
+	}
+}
+
+// TimeDelta makes the handler write the time elapsed since the previous record, instead of a timestamp. The
+// previous record is the one handled by this handler or by any handler derived from it using WithAttrs or
+// WithGroup. The time is written as seconds with millisecond precision, e.g. "   +0.125s". TimeFormat("")
+// still disables the time field.
+func TimeDelta() Option {
+	return func(h *textHandler) {
+		h.timeMode = timeDelta
+		h.delta = &deltaState{}
+	}
+}
+
+// TimeLocation sets the location used when formatting timestamps. Use [time.UTC] to write all timestamps in UTC.
+// The default is to use the location of the time of each record, which is normally [time.Local].
+func TimeLocation(loc *time.Location) Option {
+	return func(h *textHandler) {
+		h.location = loc
+	}
+}
+
+// TimeSinceOrigin makes the handler write the time elapsed since the time origin of the context given by
+// [clog.WithTimeOrigin], instead of a timestamp. The time elapsed since the start of the process is written
+// when the context has no time origin. See [TimeDelta] for the format.
+func TimeSinceOrigin() Option {
+	return func(h *textHandler) {
+		h.timeMode = timeSinceOrigin
+	}
+}
+
+// TimeSinceStart makes the handler write the time elapsed since the start of the process, instead of a
+// timestamp. See [TimeDelta] for the format.
+func TimeSinceStart() Option {
+	return func(h *textHandler) {
+		h.timeMode = timeSinceStart
+	}
+}
+
 // TimeFormat sets the time format used for log records. The records will be logged without a timestamp if the timeFormat is "".
 func TimeFormat(timeFormat string) Option {
 	return func(h *textHandler) {
@@ -0,0 +1,490 @@
+// Package otlp provides a slog.Handler that exports records to an OpenTelemetry collector using OTLP/HTTP
+// with JSON encoding, without depending on the OpenTelemetry SDK.
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/telepresenceio/clog/handler"
+	"github.com/telepresenceio/clog/internal"
+)
+
+// DefaultEndpoint is the default URL of the collector's OTLP/HTTP logs endpoint.
+const DefaultEndpoint = "http://localhost:4318/v1/logs"
+
+const (
+	defaultBatchSize    = 512
+	defaultBatchTimeout = 5 * time.Second
+)
+
+// SpanContext identifies the trace and span that a record belongs to.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+// IsValid returns true if the trace ID is not all zeroes.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{}
+}
+
+type spanContextKey struct{}
+
+// WithSpanContext assigns the span context to a child context which is returned. Records logged using the
+// returned context are exported with the trace and span IDs of the span context, unless a [SpanContextFunc]
+// option is used.
+func WithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+func spanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc
+}
+
+type Option func(*config)
+
+// BatchSize sets the number of records that triggers an export. The default is 512, which is also used
+// when n is zero or negative.
+func BatchSize(n int) Option {
+	return func(c *config) {
+		c.batchSize = n
+	}
+}
+
+// BatchTimeout sets the maximum time that a record is kept before it is exported. The default is 5 seconds,
+// which is also used when d is zero or negative.
+func BatchTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.batchTimeout = d
+	}
+}
+
+// EnabledLevel sets the minimum level of the records queued for export. The default is [slog.LevelInfo].
+func EnabledLevel(level slog.Level) Option {
+	return func(c *config) {
+		c.SetLevel(level)
+	}
+}
+
+// Endpoint sets the URL of the collector's logs endpoint. The default is [DefaultEndpoint].
+func Endpoint(url string) Option {
+	return func(c *config) {
+		c.endpoint = url
+	}
+}
+
+// Headers adds HTTP headers, such as authorization headers, to the export requests.
+func Headers(headers map[string]string) Option {
+	return func(c *config) {
+		for k, v := range headers {
+			c.headers.Set(k, v)
+		}
+	}
+}
+
+// HTTPClient sets the client used for the export requests. The default is a client with a 10 second timeout.
+func HTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
+
+// LevelEnabler sets the function that determines if the records of a level are queued for export. It replaces
+// the minimum level set by [EnabledLevel].
+func LevelEnabler(enabler handler.EnabledFunc) Option {
+	return func(c *config) {
+		c.SetEnabler(enabler)
+	}
+}
+
+// MaxQueueSize sets the maximum number of records waiting to be exported. Records are dropped when the
+// queue is full. The default is 2048.
+func MaxQueueSize(n int) Option {
+	return func(c *config) {
+		c.maxQueueSize = n
+	}
+}
+
+// Resource adds attributes that describe the resource producing the records, such as "service.name".
+func Resource(attrs ...slog.Attr) Option {
+	return func(c *config) {
+		c.resource = append(c.resource, attrs...)
+	}
+}
+
+// Retry sets the maximum number of retries of a failed export, and the initial backoff, which is doubled
+// for each retry. The defaults are 5 retries and 500 milliseconds.
+func Retry(maxRetries int, initialBackoff time.Duration) Option {
+	return func(c *config) {
+		c.maxRetries = maxRetries
+		c.backoff = initialBackoff
+	}
+}
+
+// SpanContextFunc sets the function that provides the span context of a record. It makes it possible to
+// use span contexts from a tracing library, such as the OpenTelemetry trace API. The default uses the span
+// context assigned by [WithSpanContext].
+func SpanContextFunc(f func(context.Context) SpanContext) Option {
+	return func(c *config) {
+		c.spanContext = f
+	}
+}
+
+type config struct {
+	endpoint     string
+	headers      http.Header
+	client       *http.Client
+	resource     []slog.Attr
+	batchSize    int
+	batchTimeout time.Duration
+	maxQueueSize int
+	maxRetries   int
+	backoff      time.Duration
+	spanContext  func(context.Context) SpanContext
+	internal.LevelConfig
+}
+
+// Handler is a slog.Handler that exports records in batches to an OpenTelemetry collector.
+type Handler struct {
+	exp   *exporter
+	attrs internal.AttrState
+}
+
+// New creates a Handler that exports records to the collector. Records are exported by a background goroutine
+// when a batch is full or the batch timeout expires, and when [Handler.Flush] or [Handler.Close] is called.
+// Exports that fail with a network error or a retryable HTTP status are retried with exponential backoff.
+//
+// The instrumentation scope of a record is named after the groups of the handler, joined by '/'. The
+// attributes of a record are flattened, using keys made from the keys of the attribute and its enclosing
+// groups joined by '.'.
+func New(options ...Option) *Handler {
+	cfg := &config{
+		endpoint:     DefaultEndpoint,
+		headers:      make(http.Header),
+		client:       &http.Client{Timeout: 10 * time.Second},
+		batchSize:    defaultBatchSize,
+		batchTimeout: defaultBatchTimeout,
+		maxQueueSize: 2048,
+		maxRetries:   5,
+		backoff:      500 * time.Millisecond,
+		spanContext:  spanContextFromContext,
+	}
+	for _, opt := range options {
+		opt(cfg)
+	}
+	if cfg.batchSize <= 0 {
+		cfg.batchSize = defaultBatchSize
+	}
+	if cfg.batchTimeout <= 0 {
+		cfg.batchTimeout = defaultBatchTimeout
+	}
+	e := &exporter{
+		cfg:    cfg,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+	go e.run()
+	return &Handler{exp: e}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.exp.cfg.Enabled(ctx, level)
+}
+
+// Handle converts the record to an OTLP log record and queues it for export.
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	lr := logRecord{
+		SeverityNumber: SeverityNumber(record.Level),
+		SeverityText:   severityText(record.Level),
+		Body:           anyValue{StringValue: &record.Message},
+	}
+	if !record.Time.IsZero() {
+		lr.TimeUnixNano = strconv.FormatInt(record.Time.UnixNano(), 10)
+	}
+	lr.ObservedTimeUnixNano = strconv.FormatInt(time.Now().UnixNano(), 10)
+	if sc := h.exp.cfg.spanContext(ctx); sc.IsValid() {
+		lr.TraceID = hex.EncodeToString(sc.TraceID[:])
+		lr.SpanID = hex.EncodeToString(sc.SpanID[:])
+	}
+	h.attrs.Walk(&record, func(path []string, v slog.Value) {
+		lr.Attributes = append(lr.Attributes, keyValue{Key: strings.Join(path, "."), Value: toAnyValue(v)})
+	})
+	if src := record.Source(); src != nil {
+		lr.Attributes = append(lr.Attributes,
+			keyValue{Key: "code.filepath", Value: toAnyValue(slog.StringValue(src.File))},
+			keyValue{Key: "code.lineno", Value: toAnyValue(slog.IntValue(src.Line))},
+			keyValue{Key: "code.function", Value: toAnyValue(slog.StringValue(src.Function))})
+	}
+	return h.exp.enqueue(queued{scope: strings.Join(h.attrs.Groups(), "/"), record: lr})
+}
+
+// Flush exports all queued records and returns when done or when the context is done.
+func (h *Handler) Flush(ctx context.Context) error {
+	return h.exp.flush(ctx)
+}
+
+// Close exports all queued records and stops the background goroutine. Records handled after Close
+// are dropped. Closing a closed handler has no effect. Close waits for the export to complete, including
+// retries, so [Handler.CloseContext] should be used to limit the time spent closing.
+func (h *Handler) Close() error {
+	return h.exp.close(context.Background())
+}
+
+// CloseContext is like [Handler.Close], but when ctx is done before the queued records have been exported,
+// the export is aborted, the remaining records are dropped, and the error of ctx is returned. It's used by
+// [handler.Close] and [clog.Close], so that their deadline also applies to the closing.
+func (h *Handler) CloseContext(ctx context.Context) error {
+	return h.exp.close(ctx)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{exp: h.exp, attrs: h.attrs.WithAttrs(attrs)}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{exp: h.exp, attrs: h.attrs.WithGroup(name)}
+}
+
+// SeverityNumber returns the OpenTelemetry severity number of a level. The trace levels map to TRACE (1-4),
+// [slog.LevelDebug] to DEBUG (5), [slog.LevelInfo] to INFO (9), [slog.LevelWarn] to WARN (13),
+// [slog.LevelError] to ERROR (17), and levels four above [slog.LevelError] or higher to FATAL (21-24).
+func SeverityNumber(level slog.Level) int {
+	return min(max(int(level)+9, 1), 24)
+}
+
+func severityText(level slog.Level) string {
+	switch n := SeverityNumber(level); {
+	case n <= 4:
+		return "TRACE"
+	case n <= 8:
+		return "DEBUG"
+	case n <= 12:
+		return "INFO"
+	case n <= 16:
+		return "WARN"
+	case n <= 20:
+		return "ERROR"
+	default:
+		return "FATAL"
+	}
+}
+
+type queued struct {
+	scope  string
+	record logRecord
+}
+
+var errClosed = errors.New("otlp: handler is closed")
+
+// exporter is shared by a Handler and all handlers derived from it.
+type exporter struct {
+	cfg *config
+
+	mu       sync.Mutex
+	queue    []queued
+	flushing []chan error
+	isClosed bool
+
+	wake   chan struct{}
+	done   chan struct{}
+	closed chan struct{}
+
+	// ctx is the context of the export requests. It's canceled when a close times out.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (e *exporter) enqueue(q queued) error {
+	e.mu.Lock()
+	if e.isClosed {
+		e.mu.Unlock()
+		return errClosed
+	}
+	if len(e.queue) >= e.cfg.maxQueueSize {
+		e.mu.Unlock()
+		return errors.New("otlp: queue is full, record dropped")
+	}
+	e.queue = append(e.queue, q)
+	full := len(e.queue) >= e.cfg.batchSize
+	e.mu.Unlock()
+	if full {
+		e.signal()
+	}
+	return nil
+}
+
+func (e *exporter) signal() {
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (e *exporter) flush(ctx context.Context) error {
+	ch := make(chan error, 1)
+	e.mu.Lock()
+	if e.isClosed {
+		e.mu.Unlock()
+		return errClosed
+	}
+	e.flushing = append(e.flushing, ch)
+	e.mu.Unlock()
+	e.signal()
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *exporter) close(ctx context.Context) error {
+	e.mu.Lock()
+	first := !e.isClosed
+	e.isClosed = true
+	e.mu.Unlock()
+	if first {
+		close(e.done)
+	}
+	select {
+	case <-e.closed:
+		return nil
+	case <-ctx.Done():
+		// Abort the export and wait for the background goroutine to notice.
+		e.cancel()
+		<-e.closed
+		return ctx.Err()
+	}
+}
+
+func (e *exporter) run() {
+	defer close(e.closed)
+	defer e.cancel()
+	ticker := time.NewTicker(e.cfg.batchTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.done:
+			e.exportAll()
+			return
+		case <-e.wake:
+		case <-ticker.C:
+		}
+		e.exportAll()
+	}
+}
+
+// exportAll exports the queued records in batches and notifies pending flushes.
+func (e *exporter) exportAll() {
+	e.mu.Lock()
+	flushing := e.flushing
+	e.flushing = nil
+	e.mu.Unlock()
+
+	var errs []error
+	for {
+		e.mu.Lock()
+		n := min(len(e.queue), e.cfg.batchSize)
+		batch := e.queue[:n:n]
+		e.queue = e.queue[n:]
+		e.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		if err := e.export(batch); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	err := errors.Join(errs...)
+	for _, ch := range flushing {
+		ch <- err
+	}
+}
+
+func (e *exporter) export(batch []queued) error {
+	body, err := json.Marshal(e.logsData(batch))
+	if err != nil {
+		return err
+	}
+	backoff := e.cfg.backoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := e.post(body)
+		if err == nil || retryAfter < 0 || attempt >= e.cfg.maxRetries {
+			return err
+		}
+		wait := max(backoff, retryAfter)
+		select {
+		case <-time.After(wait):
+		case <-e.ctx.Done():
+			return e.ctx.Err()
+		case <-e.done:
+			// Closing. Make one last attempt without waiting.
+			_, err = e.post(body)
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// post sends the body to the collector. It returns a negative retryAfter when the request must not be retried.
+func (e *exporter) post(body []byte) (retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(e.ctx, http.MethodPost, e.cfg.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+	for k, vs := range e.cfg.headers {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.cfg.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent:
+		return 0, nil
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			retryAfter = time.Duration(s) * time.Second
+		}
+		return retryAfter, fmt.Errorf("otlp: export failed with status %s", resp.Status)
+	default:
+		return -1, fmt.Errorf("otlp: export failed with status %s", resp.Status)
+	}
+}
+
+func (e *exporter) logsData(batch []queued) *logsData {
+	rl := resourceLogs{}
+	var attrs internal.AttrState
+	attrs.WithAttrs(e.cfg.resource).Walk(nil, func(path []string, v slog.Value) {
+		rl.Resource.Attributes = append(rl.Resource.Attributes, keyValue{Key: strings.Join(path, "."), Value: toAnyValue(v)})
+	})
+	scopes := make(map[string]int)
+	for _, q := range batch {
+		i, ok := scopes[q.scope]
+		if !ok {
+			i = len(rl.ScopeLogs)
+			scopes[q.scope] = i
+			rl.ScopeLogs = append(rl.ScopeLogs, scopeLogs{Scope: scope{Name: q.scope}})
+		}
+		rl.ScopeLogs[i].LogRecords = append(rl.ScopeLogs[i].LogRecords, q.record)
+	}
+	return &logsData{ResourceLogs: []resourceLogs{rl}}
+}
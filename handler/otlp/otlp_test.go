@@ -0,0 +1,239 @@
+package otlp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/telepresenceio/clog"
+	"github.com/telepresenceio/clog/handler"
+	"github.com/telepresenceio/clog/handler/otlp"
+)
+
+type collector struct {
+	sync.Mutex
+	failures int
+	requests int
+	bodies   []map[string]any
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.Lock()
+	defer c.Unlock()
+	c.requests++
+	if c.failures > 0 {
+		c.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	data, _ := io.ReadAll(r.Body)
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.bodies = append(c.bodies, m)
+}
+
+// path returns the value at the given path of keys and indexes.
+func path(v any, keys ...any) any {
+	for _, k := range keys {
+		switch k := k.(type) {
+		case string:
+			m, _ := v.(map[string]any)
+			v = m[k]
+		case int:
+			a, _ := v.([]any)
+			if k >= len(a) {
+				return nil
+			}
+			v = a[k]
+		}
+	}
+	return v
+}
+
+func TestHandler(t *testing.T) {
+	c := &collector{failures: 2}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	h := otlp.New(
+		otlp.Endpoint(srv.URL),
+		otlp.EnabledLevel(clog.LevelTrace),
+		otlp.Resource(slog.String("service.name", "daemon")),
+		otlp.Retry(3, 10*time.Millisecond))
+	defer h.Close()
+
+	ctx := otlp.WithSpanContext(context.Background(), otlp.SpanContext{
+		TraceID: [16]byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  [8]byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	})
+	lg := slog.New(h)
+	lg.InfoContext(ctx, "started", "port", 8080)
+	lg.WithGroup("session").With("user", "jane").Log(ctx, clog.LevelTrace, "token", slog.Group("token", "valid", true, "ttl", 1.5))
+	if err := h.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if c.requests != 3 || len(c.bodies) != 1 {
+		t.Fatalf("got %d requests and %d bodies, want 3 and 1", c.requests, len(c.bodies))
+	}
+	rl := path(c.bodies[0], "resourceLogs", 0)
+	checks := []struct {
+		keys []any
+		want any
+	}{
+		{[]any{"resource", "attributes", 0, "key"}, "service.name"},
+		{[]any{"resource", "attributes", 0, "value", "stringValue"}, "daemon"},
+		{[]any{"scopeLogs", 0, "scope", "name"}, nil},
+		{[]any{"scopeLogs", 0, "logRecords", 0, "severityNumber"}, 9.0},
+		{[]any{"scopeLogs", 0, "logRecords", 0, "severityText"}, "INFO"},
+		{[]any{"scopeLogs", 0, "logRecords", 0, "body", "stringValue"}, "started"},
+		{[]any{"scopeLogs", 0, "logRecords", 0, "traceId"}, "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{[]any{"scopeLogs", 0, "logRecords", 0, "spanId"}, "00f067aa0ba902b7"},
+		{[]any{"scopeLogs", 0, "logRecords", 0, "attributes", 0, "key"}, "port"},
+		{[]any{"scopeLogs", 0, "logRecords", 0, "attributes", 0, "value", "intValue"}, "8080"},
+		{[]any{"scopeLogs", 1, "scope", "name"}, "session"},
+		{[]any{"scopeLogs", 1, "logRecords", 0, "severityNumber"}, 1.0},
+		{[]any{"scopeLogs", 1, "logRecords", 0, "severityText"}, "TRACE"},
+		{[]any{"scopeLogs", 1, "logRecords", 0, "attributes", 0, "key"}, "session.user"},
+		{[]any{"scopeLogs", 1, "logRecords", 0, "attributes", 1, "key"}, "session.token.valid"},
+		{[]any{"scopeLogs", 1, "logRecords", 0, "attributes", 1, "value", "boolValue"}, true},
+		{[]any{"scopeLogs", 1, "logRecords", 0, "attributes", 2, "key"}, "session.token.ttl"},
+		{[]any{"scopeLogs", 1, "logRecords", 0, "attributes", 2, "value", "doubleValue"}, 1.5},
+	}
+	for _, check := range checks {
+		if got := path(rl, check.keys...); got != check.want {
+			t.Errorf("%v = %v, want %v", check.keys, got, check.want)
+		}
+	}
+}
+
+func TestHandler_batchSize(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	h := otlp.New(otlp.Endpoint(srv.URL), otlp.BatchSize(2), otlp.BatchTimeout(time.Hour))
+	lg := slog.New(h)
+	for range 5 {
+		lg.Info("hello")
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	c.Lock()
+	defer c.Unlock()
+	n := 0
+	for _, b := range c.bodies {
+		n += len(path(b, "resourceLogs", 0, "scopeLogs", 0, "logRecords").([]any))
+	}
+	if n != 5 || len(c.bodies) < 3 {
+		t.Errorf("got %d records in %d requests, want 5 records in at least 3 requests", n, len(c.bodies))
+	}
+}
+
+func TestHandler_invalidBatchOptions(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	for _, n := range []int{0, -1} {
+		h := otlp.New(otlp.Endpoint(srv.URL), otlp.BatchSize(n), otlp.BatchTimeout(time.Duration(n)))
+		slog.New(h).Info("hello")
+		if err := h.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Lock()
+	defer c.Unlock()
+	n := 0
+	for _, b := range c.bodies {
+		n += len(path(b, "resourceLogs", 0, "scopeLogs", 0, "logRecords").([]any))
+	}
+	if n != 2 {
+		t.Errorf("got %d records, want 2", n)
+	}
+}
+
+func TestHandler_nonFiniteDouble(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	h := otlp.New(otlp.Endpoint(srv.URL))
+	slog.New(h).Info("ratio", "nan", math.NaN(), "inf", math.Inf(1), "-inf", math.Inf(-1), "finite", 0.5)
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	c.Lock()
+	defer c.Unlock()
+	if len(c.bodies) != 1 {
+		t.Fatalf("got %d bodies, want 1", len(c.bodies))
+	}
+	attrs := path(c.bodies[0], "resourceLogs", 0, "scopeLogs", 0, "logRecords", 0, "attributes")
+	for i, want := range []any{"NaN", "Infinity", "-Infinity", 0.5} {
+		if got := path(attrs, i, "value", "doubleValue"); got != want {
+			t.Errorf("attribute %d = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestHandler_closeContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		// The server notices that the client is gone once the body has been read.
+		_, _ = io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	h := otlp.New(otlp.Endpoint(srv.URL), otlp.HTTPClient(&http.Client{}))
+	slog.New(h).Info("hello")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := handler.Close(ctx, h); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("closing took %s", d)
+	}
+	if err := h.Close(); err != nil {
+		t.Errorf("closing again: %v", err)
+	}
+}
+
+func TestSeverityNumber(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  int
+	}{
+		{clog.LevelTrace - 2, 1},
+		{clog.LevelTrace, 1},
+		{slog.LevelDebug, 5},
+		{slog.LevelInfo, 9},
+		{slog.LevelWarn, 13},
+		{slog.LevelError, 17},
+		{slog.LevelError + 4, 21},
+		{slog.LevelError + 40, 24},
+	}
+	for _, tt := range tests {
+		if got := otlp.SeverityNumber(tt.level); got != tt.want {
+			t.Errorf("SeverityNumber(%s) = %d, want %d", tt.level, got, tt.want)
+		}
+	}
+}
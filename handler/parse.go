@@ -0,0 +1,326 @@
+package handler
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"iter"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/telepresenceio/clog/internal"
+)
+
+// ParsedRecord is a record parsed from the output of a [NewText] handler.
+type ParsedRecord struct {
+	slog.Record
+
+	// Groups holds the groups that were written as a "group/subgroup: " prefix of the message.
+	Groups []string
+
+	// Source is the source that was written as a "(from file:line)" suffix, or nil if no source was written.
+	Source *slog.Source
+
+	// Origin is the name of the input that the record was read from. It is set by [Merge].
+	Origin string
+}
+
+type ParseOption func(*textParser)
+
+// ParseTimeFormat sets the time format of the parsed output. It must be the format given to the [TimeFormat]
+// option of the handler that produced the output. The default is [RFC3339MillisNoTz].
+func ParseTimeFormat(timeFormat string) ParseOption {
+	return func(p *textParser) {
+		p.timeFormat = timeFormat
+	}
+}
+
+// ParseLocation sets the location used for timestamps that lack time zone information. The default is [time.Local].
+func ParseLocation(loc *time.Location) ParseOption {
+	return func(p *textParser) {
+		p.loc = loc
+	}
+}
+
+// ParseDefaultLevel sets the level of records written without a level, because of a [HideLevel] option.
+// The default is [slog.LevelInfo].
+func ParseDefaultLevel(level slog.Level) ParseOption {
+	return func(p *textParser) {
+		p.defaultLevel = level
+	}
+}
+
+type textParser struct {
+	timeFormat   string
+	timeFields   int
+	loc          *time.Location
+	defaultLevel slog.Level
+}
+
+// ParseText returns an iterator over the records parsed from the output of a [NewText] handler. Lines that
+// don't start with a timestamp, or with a level when the output has no timestamps, are continuation lines
+// of a multi-line message, and are added to the message of the preceding record. The attribute values of
+// the parsed records are strings.
+//
+// The format is ambiguous in some respects: a message that starts with a word ending with ':' is parsed as
+// a group prefix, and a message containing " : " followed by text that looks like attributes is split there.
+func ParseText(r io.Reader, options ...ParseOption) iter.Seq2[*ParsedRecord, error] {
+	p := &textParser{
+		timeFormat:   RFC3339MillisNoTz,
+		loc:          time.Local,
+		defaultLevel: slog.LevelInfo,
+	}
+	for _, opt := range options {
+		opt(p)
+	}
+	if p.timeFormat != "" {
+		p.timeFields = strings.Count(p.timeFormat, " ") + 1
+	}
+	return func(yield func(*ParsedRecord, error) bool) {
+		lr := newLineReader(r)
+		var (
+			pending  bool
+			t        time.Time
+			level    slog.Level
+			hasLevel bool
+			body     strings.Builder
+		)
+		for {
+			line, idle, err := lr.next()
+			if err != nil {
+				if pending && !yield(p.record(t, level, body.String()), nil) {
+					return
+				}
+				if err != io.EOF {
+					yield(nil, err)
+				}
+				return
+			}
+			if idle {
+				// No more lines are available yet, so the pending record is complete as far as we know.
+				if pending && !yield(p.record(t, level, body.String()), nil) {
+					return
+				}
+				pending = false
+				continue
+			}
+			lt, ll, lHasLevel, rest, ok := p.parseHeader(line)
+			if !ok || (p.timeFormat == "" && !lHasLevel && hasLevel) {
+				if pending {
+					body.WriteByte('\n')
+					body.WriteString(line)
+					continue
+				}
+				rest = line
+			}
+			if pending && !yield(p.record(t, level, body.String()), nil) {
+				return
+			}
+			pending = true
+			t, level, hasLevel = lt, ll, lHasLevel
+			body.Reset()
+			body.WriteString(rest)
+		}
+	}
+}
+
+// parseHeader parses the timestamp and the level of a line, and returns the remainder of the line.
+// It returns false if the line doesn't start with a timestamp.
+func (p *textParser) parseHeader(line string) (t time.Time, level slog.Level, hasLevel bool, rest string, ok bool) {
+	rest = line
+	if p.timeFormat != "" {
+		end := 0
+		for i := 0; i < p.timeFields; i++ {
+			n := strings.IndexByte(rest[end:], ' ')
+			if n < 0 {
+				return t, level, false, line, false
+			}
+			end += n + 1
+		}
+		var err error
+		if t, err = time.ParseInLocation(p.timeFormat, rest[:end-1], p.loc); err != nil {
+			return t, level, false, line, false
+		}
+		rest = rest[end:]
+	}
+	level = p.defaultLevel
+	if n := strings.IndexByte(rest, ' '); n > 0 {
+		name := stripColor(rest[:n])
+		if l, lok := internal.ParseLevelName(name); lok {
+			level, hasLevel = l, true
+			rest = rest[n+1:]
+			// Skip the padding of the level column.
+			for i := len(name); i < internal.MaxLevelNameLen() && strings.HasPrefix(rest, " "); i++ {
+				rest = rest[1:]
+			}
+		}
+	}
+	return t, level, hasLevel, rest, true
+}
+
+// stripColor removes the ANSI color escape sequences that are written around the level by the [Color] option.
+func stripColor(s string) string {
+	for {
+		i := strings.Index(s, "\x1b[")
+		if i < 0 {
+			return s
+		}
+		e := strings.IndexByte(s[i:], 'm')
+		if e < 0 {
+			return s
+		}
+		s = s[:i] + s[i+e+1:]
+	}
+}
+
+// record creates a record from the body of an entry, i.e. the text that follows the level.
+func (p *textParser) record(t time.Time, level slog.Level, body string) *ParsedRecord {
+	pr := &ParsedRecord{}
+	if strings.HasSuffix(body, ")") {
+		if i := strings.LastIndex(body, " (from "); i >= 0 {
+			src := body[i+7 : len(body)-1]
+			if c := strings.LastIndexByte(src, ':'); c > 0 {
+				if line, err := strconv.Atoi(src[c+1:]); err == nil {
+					pr.Source = &slog.Source{File: src[:c], Line: line}
+					body = body[:i]
+				}
+			}
+		}
+	}
+	if sp := strings.IndexByte(body, ' '); sp > 1 && body[sp-1] == ':' && !strings.ContainsRune(body[:sp], '\n') {
+		pr.Groups = strings.Split(body[:sp-1], "/")
+		body = body[sp+1:]
+	}
+	msg := body
+	var attrs []slog.Attr
+	for i := 0; ; {
+		n := strings.Index(body[i:], " : ")
+		if n < 0 {
+			break
+		}
+		i += n
+		if as, err := parseAttrs(body[i+3:]); err == nil {
+			msg, attrs = body[:i], as
+			break
+		}
+		i++
+	}
+	pr.Record = slog.NewRecord(t, level, msg, 0)
+	pr.AddAttrs(attrs...)
+	return pr
+}
+
+var errSyntax = errors.New("invalid attribute syntax")
+
+// parseAttrs parses space separated attributes written by addAttr. The whole string must be consumed.
+func parseAttrs(s string) ([]slog.Attr, error) {
+	attrs, rest, err := parseAttrList(s, false)
+	if err == nil && rest != "" {
+		err = errSyntax
+	}
+	return attrs, err
+}
+
+func parseAttrList(s string, inGroup bool) (attrs []slog.Attr, rest string, err error) {
+	for {
+		var a slog.Attr
+		if a, s, err = parseAttr(s, inGroup); err != nil {
+			return nil, s, err
+		}
+		attrs = append(attrs, a)
+		if !strings.HasPrefix(s, " ") {
+			return attrs, s, nil
+		}
+		s = s[1:]
+	}
+}
+
+func parseAttr(s string, inGroup bool) (slog.Attr, string, error) {
+	eq := strings.IndexByte(s, '=')
+	if eq <= 0 || strings.ContainsAny(s[:eq], " {}\"\n") {
+		return slog.Attr{}, s, errSyntax
+	}
+	key := s[:eq]
+	s = s[eq+1:]
+	switch {
+	case strings.HasPrefix(s, "{"):
+		attrs, rest, err := parseAttrList(s[1:], true)
+		if err != nil || !strings.HasPrefix(rest, "}") {
+			return slog.Attr{}, s, errSyntax
+		}
+		keys := strings.Split(key, "/")
+		a := slog.GroupAttrs(keys[len(keys)-1], attrs...)
+		for i := len(keys) - 2; i >= 0; i-- {
+			a = slog.GroupAttrs(keys[i], a)
+		}
+		return a, rest[1:], nil
+	case strings.HasPrefix(s, `"`):
+		end := 1
+		for ; end < len(s) && s[end] != '"'; end++ {
+			if s[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(s) {
+			return slog.Attr{}, s, errSyntax
+		}
+		v, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return slog.Attr{}, s, errSyntax
+		}
+		return slog.String(key, v), s[end+1:], nil
+	default:
+		end := strings.IndexAny(s, " \n")
+		if inGroup {
+			if b := strings.IndexByte(s, '}'); b >= 0 && (end < 0 || b < end) {
+				end = b
+			}
+		}
+		if end < 0 {
+			end = len(s)
+		}
+		if strings.ContainsAny(s[:end], `="`) {
+			return slog.Attr{}, s, errSyntax
+		}
+		return slog.String(key, s[:end]), s[end:], nil
+	}
+}
+
+// lineReader reads lines, like a [bufio.Scanner] using [bufio.ScanLines], from a reader that may return
+// [internal.ErrIdle] when it has no more data yet, such as a followed file.
+type lineReader struct {
+	br      *bufio.Reader
+	partial strings.Builder
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{br: bufio.NewReader(r)}
+}
+
+// next returns the next line. It returns true instead of a line when the reader is idle between lines.
+// The error is io.EOF at the end of the input.
+func (lr *lineReader) next() (line string, idle bool, err error) {
+	for {
+		s, err := lr.br.ReadString('\n')
+		lr.partial.WriteString(s)
+		switch {
+		case err == nil:
+			line = strings.TrimSuffix(lr.partial.String(), "\n")
+			lr.partial.Reset()
+			return strings.TrimSuffix(line, "\r"), false, nil
+		case errors.Is(err, internal.ErrIdle):
+			if lr.partial.Len() == 0 {
+				return "", true, nil
+			}
+			// Wait for the rest of the line.
+		case err == io.EOF && lr.partial.Len() > 0:
+			line = lr.partial.String()
+			lr.partial.Reset()
+			return strings.TrimSuffix(line, "\r"), false, nil
+		default:
+			return "", false, err
+		}
+	}
+}
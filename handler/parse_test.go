@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/telepresenceio/clog/internal"
+)
+
+func TestParseText_roundTrip(t *testing.T) {
+	ctx := context.Background()
+	var out bytes.Buffer
+	opts := []Option{
+		EnabledLevel(slog.LevelDebug - 8),
+		Output(&out),
+	}
+	lg := slog.New(NewText(opts...))
+	lg.Info("plain")
+	lg.Warn("")
+	lg.Error("with attrs", "k", "v", "quoted", `a "b" = c`, "empty", "")
+	lg.Log(ctx, slog.LevelDebug-5, "trace minus one", "n", 1)
+	lg.Log(ctx, slog.LevelError+4, "error plus four")
+	lg.Info("multi\nline : message", "k", "line1\nline2")
+	lg.Info("nested", slog.Group("a", "x", 1, slog.Group("b", "y", "z w")), "after", true)
+	lg.Info("standalone", slog.Group("g", slog.Group("h", "x", 1, "y", 2)))
+	lg.WithGroup("svc").Info("in group", "k", "v")
+	lg.WithGroup("svc").WithGroup("sub").Info("looks like : attrs x=", "k", "v")
+
+	var again bytes.Buffer
+	for r, err := range ParseText(bytes.NewReader(out.Bytes())) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		var h slog.Handler = NewText(EnabledLevel(slog.LevelDebug-8), Output(&again))
+		for _, g := range r.Groups {
+			h = h.WithGroup(g)
+		}
+		if err := h.Handle(ctx, r.Record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out.String() != again.String() {
+		t.Errorf("round trip mismatch\nwant:\n%s\ngot:\n%s", out.String(), again.String())
+	}
+}
+
+func TestParseText_options(t *testing.T) {
+	input := "03:04:05 first : k=v (from /src/main.go:12)\n  continued\n03:04:06 second"
+	var recs []*ParsedRecord
+	for r, err := range ParseText(strings.NewReader(input),
+		ParseTimeFormat(time.TimeOnly), ParseLocation(time.UTC), ParseDefaultLevel(slog.LevelWarn)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, r)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("got %d records, want 2", len(recs))
+	}
+	r := recs[0]
+	if r.Level != slog.LevelWarn || r.Time.Second() != 5 {
+		t.Errorf("unexpected level or time: %s %s", r.Level, r.Time)
+	}
+	if r.Message != "first : k=v (from /src/main.go:12)\n  continued" {
+		t.Errorf("unexpected message %q", r.Message)
+	}
+	if r.Source != nil || r.NumAttrs() != 0 {
+		t.Errorf("attrs and source must be parsed from the end of the entry")
+	}
+	if recs[1].Message != "second" {
+		t.Errorf("unexpected message %q", recs[1].Message)
+	}
+}
+
+// idleReader returns its chunks one by one, and internal.ErrIdle for each empty chunk.
+type idleReader struct {
+	chunks []string
+	read   int
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	if r.read == len(r.chunks) {
+		return 0, io.EOF
+	}
+	c := r.chunks[r.read]
+	r.read++
+	if c == "" {
+		return 0, internal.ErrIdle
+	}
+	return copy(p, c), nil
+}
+
+func TestParseText_idle(t *testing.T) {
+	r := &idleReader{chunks: []string{"INFO  first\n", "", "INFO  sec", "", "ond\n  continued\n", ""}}
+	var got []string
+	for pr, err := range ParseText(r, ParseTimeFormat("")) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		// A record must be yielded as soon as the reader is idle, without reading further.
+		got = append(got, fmt.Sprintf("%d %s", r.read, pr.Message))
+	}
+	want := []string{"2 first", "6 second\n  continued"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
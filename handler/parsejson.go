@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/telepresenceio/clog/internal"
+)
+
+// ParseJSON returns an iterator over the records parsed from the output of a [slog.JSONHandler], one JSON
+// object per line. The built-in "time", "level", "msg", and "source" keys are used for the corresponding
+// fields of the record, and all other keys become attributes, with objects as groups. JSON numbers that
+// are integers become int64 values, and other numbers become float64 values.
+//
+// A line that can't be parsed results in an error, after which the iteration may continue with the next line.
+// The Groups of the parsed records are always empty, because the JSON output doesn't distinguish the groups
+// of a handler from groups given as attributes.
+func ParseJSON(r io.Reader) iter.Seq2[*ParsedRecord, error] {
+	return func(yield func(*ParsedRecord, error) bool) {
+		lr := newLineReader(r)
+		lineNo := 0
+		for {
+			line, idle, err := lr.next()
+			if err != nil {
+				if err != io.EOF {
+					yield(nil, err)
+				}
+				return
+			}
+			if idle {
+				continue
+			}
+			lineNo++
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			pr, err := parseJSONLine(line)
+			if err != nil {
+				err = fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			if !yield(pr, err) {
+				return
+			}
+		}
+	}
+}
+
+func parseJSONLine(line string) (*ParsedRecord, error) {
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	if t, err := dec.Token(); err != nil {
+		return nil, err
+	} else if t != json.Delim('{') {
+		return nil, errors.New("not a JSON object")
+	}
+	attrs, err := decodeObject(dec)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		t     time.Time
+		level = slog.LevelInfo
+		msg   string
+		src   *slog.Source
+	)
+	rest := attrs[:0]
+	for _, a := range attrs {
+		switch a.Key {
+		case slog.TimeKey:
+			if t, err = time.Parse(time.RFC3339Nano, a.Value.String()); err != nil {
+				return nil, err
+			}
+		case slog.LevelKey:
+			var ok bool
+			if level, ok = internal.ParseLevelName(a.Value.String()); !ok {
+				return nil, fmt.Errorf("invalid level %q", a.Value.String())
+			}
+		case slog.MessageKey:
+			msg = a.Value.String()
+		case slog.SourceKey:
+			if a.Value.Kind() != slog.KindGroup {
+				rest = append(rest, a)
+				break
+			}
+			src = &slog.Source{}
+			for _, sa := range a.Value.Group() {
+				switch sa.Key {
+				case "function":
+					src.Function = sa.Value.String()
+				case "file":
+					src.File = sa.Value.String()
+				case "line":
+					src.Line = int(sa.Value.Int64())
+				}
+			}
+		default:
+			rest = append(rest, a)
+		}
+	}
+	pr := &ParsedRecord{Record: slog.NewRecord(t, level, msg, 0), Source: src}
+	pr.AddAttrs(rest...)
+	return pr, nil
+}
+
+// decodeObject decodes the members of an object whose opening delimiter has been consumed.
+func decodeObject(dec *json.Decoder) ([]slog.Attr, error) {
+	var attrs []slog.Attr
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := t.(string)
+		v, err := decodeValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		if group, ok := v.([]slog.Attr); ok {
+			attrs = append(attrs, slog.GroupAttrs(key, group...))
+			continue
+		}
+		attrs = append(attrs, slog.Any(key, v))
+	}
+	_, err := dec.Token() // '}'
+	return attrs, err
+}
+
+// decodeValue decodes a value, which is returned as a []slog.Attr if it is an object.
+func decodeValue(dec *json.Decoder) (any, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := t.(type) {
+	case json.Delim:
+		if t == '{' {
+			return decodeObject(dec)
+		}
+		var vs []any
+		for dec.More() {
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			if attrs, ok := v.([]slog.Attr); ok {
+				m := make(map[string]any, len(attrs))
+				for _, a := range attrs {
+					m[a.Key] = a.Value.Any()
+				}
+				v = m
+			}
+			vs = append(vs, v)
+		}
+		_, err = dec.Token() // ']'
+		return vs, err
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	default:
+		return t, nil
+	}
+}
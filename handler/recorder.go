@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/telepresenceio/clog/internal"
+)
+
+type RecorderOption func(*recorder)
+
+// RecordLevel sets the lowest level of the records kept by the flight recorder. The default is
+// the trace level (four below [slog.LevelDebug]).
+func RecordLevel(level slog.Level) RecorderOption {
+	return func(r *recorder) {
+		r.recordLevel = level
+	}
+}
+
+// RecordLimit sets the maximum number of records kept by the flight recorder. The default is 100.
+// A limit of zero or less disables the recording.
+func RecordLimit(n int) RecorderOption {
+	return func(r *recorder) {
+		r.limit = max(n, 0)
+	}
+}
+
+// RecordMaxAge makes the flight recorder discard records that are older than the given duration
+// when a flush is triggered. The default is zero, meaning that records never expire.
+func RecordMaxAge(d time.Duration) RecorderOption {
+	return func(r *recorder) {
+		r.maxAge = d
+	}
+}
+
+// FlushLevel sets the level that triggers a flush of the kept records. The default is [slog.LevelError].
+func FlushLevel(level slog.Level) RecorderOption {
+	return func(r *recorder) {
+		r.flushLevel = level
+	}
+}
+
+// NewFlightRecorder creates a new slog.Handler that passes records enabled by the inner handler on to it,
+// and keeps the most recent records that are not enabled by the inner handler in a ring buffer. The kept
+// records are passed on to the inner handler, in the order that they were logged, when a record at the
+// flush level is handled. This gives access to verbose records around a failure without paying the cost of
+// writing them all the time.
+//
+// The ring buffer is scoped to the tree created by [clog.WithTreeLevel] when the context has one, so that
+// a flush only reveals records logged in that subtree. Records logged using contexts without a tree share
+// one global ring buffer.
+func NewFlightRecorder(inner slog.Handler, options ...RecorderOption) slog.Handler {
+	r := &recorder{
+		recordLevel: slog.LevelDebug - 4,
+		flushLevel:  slog.LevelError,
+		limit:       100,
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+	r.global.entries = make([]recorded, r.limit)
+	return &flightRecorder{inner: inner, recorder: r}
+}
+
+type flightRecorder struct {
+	inner    slog.Handler
+	recorder *recorder
+}
+
+// recorder is the state shared between a flight recorder and its derived handlers.
+type recorder struct {
+	recordLevel slog.Level
+	flushLevel  slog.Level
+	limit       int
+	maxAge      time.Duration
+	global      ring
+}
+
+// recorded is a kept record, along with the handler that it must be passed on to.
+type recorded struct {
+	h slog.Handler
+	r slog.Record
+}
+
+type ring struct {
+	sync.Mutex
+	entries []recorded
+	next    int
+	full    bool
+}
+
+func (r *ring) add(e recorded) {
+	r.Lock()
+	r.entries[r.next] = e
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.full = true
+	}
+	r.Unlock()
+}
+
+// take returns the kept entries in the order they were added, and empties the ring.
+func (r *ring) take() []recorded {
+	r.Lock()
+	defer r.Unlock()
+	var es []recorded
+	if r.full {
+		es = append(es, r.entries[r.next:]...)
+	}
+	es = append(es, r.entries[:r.next]...)
+	clear(r.entries)
+	r.next = 0
+	r.full = false
+	return es
+}
+
+func (h *flightRecorder) ring(ctx context.Context) *ring {
+	if t := internal.TreeFrom(ctx); t != nil {
+		return t.Value(h.recorder, func() any {
+			return &ring{entries: make([]recorded, h.recorder.limit)}
+		}).(*ring)
+	}
+	return &h.recorder.global
+}
+
+func (h *flightRecorder) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.recorder.recordLevel || h.inner.Enabled(ctx, level)
+}
+
+func (h *flightRecorder) Handle(ctx context.Context, record slog.Record) error {
+	return h.HandleFormat(ctx, &record, nil)
+}
+
+func (h *flightRecorder) HandleFormat(ctx context.Context, record *slog.Record, fmtArgs []any) error {
+	if record.Level >= h.recorder.flushLevel {
+		h.flush(ctx, record.Time)
+	}
+	if h.inner.Enabled(ctx, record.Level) {
+		return handleFormat(ctx, h.inner, record, fmtArgs)
+	}
+	if record.Level >= h.recorder.recordLevel && h.recorder.limit > 0 {
+		// The arguments and attribute values may change before the record is flushed, so format the
+		// message and resolve the attributes now.
+		msg := record.Message
+		if len(fmtArgs) > 0 {
+			msg = fmt.Sprintf(msg, fmtArgs...)
+		}
+		kept := slog.NewRecord(record.Time, record.Level, msg, record.PC)
+		record.Attrs(func(a slog.Attr) bool {
+			kept.AddAttrs(internal.ResolveAttr(a))
+			return true
+		})
+		h.ring(ctx).add(recorded{h: h.inner, r: kept})
+	}
+	return nil
+}
+
+func (h *flightRecorder) flush(ctx context.Context, now time.Time) {
+	for _, e := range h.ring(ctx).take() {
+		if h.recorder.maxAge > 0 && now.Sub(e.r.Time) > h.recorder.maxAge {
+			continue
+		}
+		_ = e.h.Handle(ctx, e.r)
+	}
+}
+
+// Unwrap returns the inner handler, which receives the records that it enables, and the kept records when
+// a record at the flush level is handled.
+func (h *flightRecorder) Unwrap() slog.Handler {
+	return h.inner
+}
+
+func (h *flightRecorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &flightRecorder{inner: h.inner.WithAttrs(attrs), recorder: h.recorder}
+}
+
+func (h *flightRecorder) WithGroup(name string) slog.Handler {
+	return &flightRecorder{inner: h.inner.WithGroup(name), recorder: h.recorder}
+}
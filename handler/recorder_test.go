@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestFlightRecorder_noLimit(t *testing.T) {
+	for _, limit := range []int{0, -1} {
+		var buf bytes.Buffer
+		h := NewFlightRecorder(NewText(Output(&buf), TimeFormat("")), RecordLimit(limit))
+		lg := slog.New(h)
+		lg.DebugContext(context.Background(), "cache miss")
+		lg.ErrorContext(context.Background(), "query failed")
+		if got, want := buf.String(), "ERROR query failed\n"; got != want {
+			t.Errorf("limit %d: got %q, want %q", limit, got, want)
+		}
+	}
+}
+
+// counter is a slog.LogValuer with a value that changes after it's logged.
+type counter struct{ n int }
+
+func (c *counter) LogValue() slog.Value {
+	return slog.IntValue(c.n)
+}
+
+func TestFlightRecorder_resolvesWhenKept(t *testing.T) {
+	var buf bytes.Buffer
+	lg := slog.New(NewFlightRecorder(NewText(Output(&buf), TimeFormat(""), EnabledLevel(slog.LevelInfo))))
+	c := &counter{n: 1}
+	lg.Debug("retrying", "attempt", c, slog.Group("g", "attempt", c))
+	c.n = 2
+	lg.Error("failed", "attempt", c)
+	want := "DEBUG retrying : attempt=1 g={attempt=1}\nERROR failed : attempt=2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,330 @@
+// Package syslog provides a slog.Handler that sends records to a syslog daemon using the RFC 5424 format.
+package syslog
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/telepresenceio/clog/handler"
+	"github.com/telepresenceio/clog/internal"
+	"github.com/telepresenceio/clog/internal/netconn"
+)
+
+// Facility is a syslog facility.
+type Facility int
+
+const (
+	Kern Facility = iota
+	User
+	Mail
+	Daemon
+	Auth
+	Syslog
+	Lpr
+	News
+	Uucp
+	Cron
+	AuthPriv
+	Ftp
+	Local0 Facility = iota + 4
+	Local1
+	Local2
+	Local3
+	Local4
+	Local5
+	Local6
+	Local7
+)
+
+// Syslog severities, as defined by RFC 5424.
+const (
+	SeverityEmergency = iota
+	SeverityAlert
+	SeverityCritical
+	SeverityError
+	SeverityWarning
+	SeverityNotice
+	SeverityInfo
+	SeverityDebug
+)
+
+// Severity returns the syslog severity that corresponds to a level. Levels at four above [slog.LevelError]
+// or higher are critical, and levels at two above [slog.LevelInfo] up to [slog.LevelWarn] are notices.
+// All levels below [slog.LevelInfo], including the trace levels, are debug.
+func Severity(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError+4:
+		return SeverityCritical
+	case level >= slog.LevelError:
+		return SeverityError
+	case level >= slog.LevelWarn:
+		return SeverityWarning
+	case level >= slog.LevelInfo+2:
+		return SeverityNotice
+	case level >= slog.LevelInfo:
+		return SeverityInfo
+	default:
+		return SeverityDebug
+	}
+}
+
+type Option func(*config)
+
+// AppName sets the APP-NAME of the messages. The default is the base name of the executable.
+func AppName(name string) Option {
+	return func(c *config) {
+		c.appName = name
+	}
+}
+
+// EnabledLevel sets the minimum level of the records sent to the syslog daemon. The default is [slog.LevelInfo].
+func EnabledLevel(level slog.Level) Option {
+	return func(c *config) {
+		c.SetLevel(level)
+	}
+}
+
+// UseFacility sets the facility of the messages. The default is [User].
+func UseFacility(facility Facility) Option {
+	return func(c *config) {
+		c.facility = facility
+	}
+}
+
+// Hostname sets the HOSTNAME of the messages. The default is the name reported by [os.Hostname].
+func Hostname(name string) Option {
+	return func(c *config) {
+		c.hostname = name
+	}
+}
+
+// IncludeSource adds the source file and line number of the record as a "source" structured data parameter.
+func IncludeSource(include bool) Option {
+	return func(c *config) {
+		c.includeSource = include
+	}
+}
+
+// LevelEnabler sets the function that determines if the records of a level are sent to the syslog daemon. It replaces
+// the minimum level set by [EnabledLevel].
+func LevelEnabler(enabler handler.EnabledFunc) Option {
+	return func(c *config) {
+		c.SetEnabler(enabler)
+	}
+}
+
+// MsgID sets the MSGID of the messages. The default is "-", meaning that no MSGID is present.
+func MsgID(id string) Option {
+	return func(c *config) {
+		c.msgID = id
+	}
+}
+
+// ProcID sets the PROCID of the messages. The default is the process ID.
+func ProcID(id string) Option {
+	return func(c *config) {
+		c.procID = id
+	}
+}
+
+// SDID sets the SD-ID of the structured data element that holds the attributes of the records.
+// The default is "clog@32473".
+func SDID(id string) Option {
+	return func(c *config) {
+		c.sdID = id
+	}
+}
+
+// Timeout sets the timeout used when dialing the syslog daemon and when writing a message to it.
+// The default is 5 seconds.
+func Timeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+type config struct {
+	facility      Facility
+	hostname      string
+	appName       string
+	procID        string
+	msgID         string
+	sdID          string
+	timeout       time.Duration
+	includeSource bool
+	internal.LevelConfig
+}
+
+// Handler is a slog.Handler that writes each record as a syslog message.
+type Handler struct {
+	cfg   *config
+	conn  *netconn.Conn
+	attrs internal.AttrState
+}
+
+// localSockets are the unix datagram sockets tried when no network is given to Dial.
+var localSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// Dial creates a Handler that sends messages to the syslog daemon at the given address. The network is one of
+// "udp", "tcp", "unixgram", or "unix", or one of their variants such as "udp4". Messages sent over a stream
+// network use octet-counting framing. When network is empty, the local syslog daemon is used.
+//
+// The connection is reestablished if a write fails.
+func Dial(network, addr string, options ...Option) (*Handler, error) {
+	cfg := &config{
+		facility: User,
+		appName:  filepath.Base(os.Args[0]),
+		procID:   strconv.Itoa(os.Getpid()),
+		msgID:    "-",
+		sdID:     "clog@32473",
+		timeout:  netconn.DefaultTimeout,
+	}
+	cfg.hostname, _ = os.Hostname()
+	for _, opt := range options {
+		opt(cfg)
+	}
+	var conn *netconn.Conn
+	var err error
+	if network == "" {
+		for _, addr = range localSockets {
+			if conn, err = netconn.Dial("unixgram", addr, cfg.timeout); err == nil {
+				break
+			}
+		}
+	} else {
+		conn, err = netconn.Dial(network, addr, cfg.timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{cfg: cfg, conn: conn}, nil
+}
+
+// Close closes the connection to the syslog daemon.
+func (h *Handler) Close() error {
+	return h.conn.Close()
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.cfg.Enabled(ctx, level)
+}
+
+// Handle writes the record as a syslog message.
+func (h *Handler) Handle(_ context.Context, record slog.Record) error {
+	msg := h.format(&record)
+	if h.conn.IsStream() {
+		framed := make([]byte, 0, len(msg)+8)
+		framed = strconv.AppendInt(framed, int64(len(msg)), 10)
+		framed = append(framed, ' ')
+		msg = append(framed, msg...)
+	}
+	_, err := h.conn.Write(msg)
+	return err
+}
+
+func (h *Handler) format(record *slog.Record) []byte {
+	cfg := h.cfg
+	b := make([]byte, 0, 256)
+	b = append(b, '<')
+	b = strconv.AppendInt(b, int64(int(cfg.facility)*8+Severity(record.Level)), 10)
+	b = append(b, ">1 "...)
+	if record.Time.IsZero() {
+		b = append(b, '-')
+	} else {
+		b = record.Time.AppendFormat(b, "2006-01-02T15:04:05.000000Z07:00")
+	}
+	b = append(b, ' ')
+	b = appendHeaderField(b, cfg.hostname, 255)
+	b = append(b, ' ')
+	b = appendHeaderField(b, cfg.appName, 48)
+	b = append(b, ' ')
+	b = appendHeaderField(b, cfg.procID, 128)
+	b = append(b, ' ')
+	b = appendHeaderField(b, cfg.msgID, 32)
+	b = append(b, ' ')
+
+	sdStart := len(b)
+	b = append(b, '[')
+	b = append(b, cfg.sdID...)
+	n := len(b)
+	h.attrs.Walk(record, func(path []string, v slog.Value) {
+		b = appendParam(b, strings.Join(path, "."), v.String())
+	})
+	if cfg.includeSource {
+		if src := record.Source(); src != nil {
+			b = appendParam(b, "source", src.File+":"+strconv.Itoa(src.Line))
+		}
+	}
+	if len(b) == n {
+		b = append(b[:sdStart], '-')
+	} else {
+		b = append(b, ']')
+	}
+
+	if msg := h.attrs.Message(record.Message); msg != "" {
+		b = append(b, ' ')
+		b = append(b, msg...)
+	}
+	return b
+}
+
+// appendHeaderField appends s, restricted to printable US-ASCII and truncated to maxLen, or "-" if s is empty.
+func appendHeaderField(b []byte, s string, maxLen int) []byte {
+	n := len(b)
+	for i := 0; i < len(s) && len(b)-n < maxLen; i++ {
+		if c := s[i]; c >= 33 && c <= 126 {
+			b = append(b, c)
+		}
+	}
+	if len(b) == n {
+		b = append(b, '-')
+	}
+	return b
+}
+
+// appendParam appends an SD-PARAM. Characters that aren't allowed in a PARAM-NAME are replaced
+// by '_', and the characters '"', '\\', and ']' are escaped in the PARAM-VALUE.
+func appendParam(b []byte, name, value string) []byte {
+	b = append(b, ' ')
+	n := len(b)
+	for i := 0; i < len(name) && len(b)-n < 32; i++ {
+		c := name[i]
+		if c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	if len(b) == n {
+		b = append(b, '_')
+	}
+	b = append(b, `="`...)
+	for _, r := range value {
+		switch r {
+		case '"', '\\', ']':
+			b = append(b, '\\', byte(r))
+		case utf8.RuneError:
+			b = append(b, "�"...)
+		default:
+			b = utf8.AppendRune(b, r)
+		}
+	}
+	return append(b, '"')
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = h.attrs.WithAttrs(attrs)
+	return &h2
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.attrs = h.attrs.WithGroup(name)
+	return &h2
+}
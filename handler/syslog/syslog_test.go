@@ -0,0 +1,174 @@
+package syslog_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/telepresenceio/clog"
+	"github.com/telepresenceio/clog/handler/syslog"
+)
+
+var testTime = time.Date(2026, 1, 2, 3, 4, 5, 678900000, time.UTC)
+
+func logRecords(t *testing.T, h *syslog.Handler) {
+	t.Helper()
+	lg := slog.New(h).With("user", "jane").WithGroup("session").With("id", 7)
+	r := slog.NewRecord(testTime, slog.LevelWarn, "token expires soon", 0)
+	r.AddAttrs(slog.Group("token", slog.String("id", `a"b]c`), slog.Duration("ttl", time.Minute)))
+	if err := lg.Handler().Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	r = slog.NewRecord(testTime, clog.LevelTrace, "trace", 0)
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const (
+	wantWarn  = `<12>1 2026-01-02T03:04:05.678900Z host app 42 - [clog@32473 user="jane" session.id="7" session.token.id="a\"b\]c" session.token.ttl="1m0s"] session: token expires soon`
+	wantTrace = `<15>1 2026-01-02T03:04:05.678900Z host app 42 - - trace`
+)
+
+func opts() []syslog.Option {
+	return []syslog.Option{syslog.Hostname("host"), syslog.AppName("app"), syslog.ProcID("42"), syslog.Timeout(time.Second)}
+}
+
+func TestDial_udp(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	h, err := syslog.Dial("udp", pc.LocalAddr().String(), opts()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	logRecords(t, h)
+	for _, want := range []string{wantWarn, wantTrace} {
+		buf := make([]byte, 4096)
+		_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); got != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	}
+}
+
+func TestDial_tcp(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	h, err := syslog.Dial("tcp", l.Addr().String(), opts()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	logRecords(t, h)
+
+	rd := bufio.NewReader(conn)
+	for _, want := range []string{wantWarn, wantTrace} {
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		ls, err := rd.ReadString(' ')
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(ls))
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := make([]byte, n)
+		if _, err = io.ReadFull(rd, msg); err != nil {
+			t.Fatal(err)
+		}
+		if got := string(msg); got != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	}
+}
+
+func TestDial_unixgramReconnect(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "log.sock")
+	listen := func() net.PacketConn {
+		pc, err := net.ListenPacket("unixgram", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pc
+	}
+	read := func(pc net.PacketConn) string {
+		buf := make([]byte, 4096)
+		_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf[:n])
+	}
+
+	pc := listen()
+	h, err := syslog.Dial("unixgram", addr, opts()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	r := slog.NewRecord(testTime, slog.LevelInfo, "first", 0)
+	if err = h.Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(pc); !strings.HasSuffix(got, " first") {
+		t.Errorf("unexpected message %q", got)
+	}
+
+	// Restart the daemon.
+	_ = pc.Close()
+	_ = os.Remove(addr)
+	pc = listen()
+	defer pc.Close()
+	r = slog.NewRecord(testTime, slog.LevelInfo, "second", 0)
+	if err = h.Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(pc); !strings.HasSuffix(got, " second") {
+		t.Errorf("unexpected message %q", got)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  int
+	}{
+		{clog.LevelTrace, syslog.SeverityDebug},
+		{slog.LevelDebug, syslog.SeverityDebug},
+		{slog.LevelInfo, syslog.SeverityInfo},
+		{slog.LevelInfo + 2, syslog.SeverityNotice},
+		{slog.LevelWarn, syslog.SeverityWarning},
+		{slog.LevelError, syslog.SeverityError},
+		{slog.LevelError + 4, syslog.SeverityCritical},
+	}
+	for _, tt := range tests {
+		if got := syslog.Severity(tt.level); got != tt.want {
+			t.Errorf("Severity(%s) = %d, want %d", tt.level, got, tt.want)
+		}
+	}
+}
@@ -3,11 +3,16 @@ package handler
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"math"
 	"os"
 	"strconv"
+	"sync"
+	"time"
 	"unicode"
+
+	"github.com/telepresenceio/clog/internal"
 )
 
 const RFC3339MillisNoTz = "2006-01-02T15:04:05.000"
@@ -38,14 +43,14 @@ func (h *textHandler) Handle(ctx context.Context, record slog.Record) error {
 	return h.HandleFormat(ctx, &record, nil)
 }
 
-func (h *textHandler) HandleFormat(_ context.Context, record *slog.Record, fmtArgs []any) error {
+func (h *textHandler) HandleFormat(ctx context.Context, record *slog.Record, fmtArgs []any) error {
 	buf := newBuf()
 	if h.timeFormat != "" {
-		buf.writeString(record.Time.Format(h.timeFormat))
+		h.writeTime(ctx, record.Time, buf)
 		buf.writeByte(' ')
 	}
 	if record.Level < h.hideLevelsAbove {
-		levelString(record.Level, buf)
+		levelString(record.Level, h.color, buf)
 	}
 
 	hasGroups := false
@@ -66,6 +71,7 @@ func (h *textHandler) HandleFormat(_ context.Context, record *slog.Record, fmtAr
 	// Merge stand-alone top level group into groups.
 	if record.NumAttrs() == 1 {
 		record.Attrs(func(a slog.Attr) bool {
+			a.Value = a.Value.Resolve()
 			if a.Value.Kind() == slog.KindGroup {
 				writeGroup(a.Key)
 				ga := a.Value.Group()
@@ -110,6 +116,9 @@ func (h *textHandler) HandleFormat(_ context.Context, record *slog.Record, fmtAr
 	}
 	if h.includeSource {
 		src := record.Source()
+		if src == nil {
+			src = internal.Source(ctx)
+		}
 		if src != nil {
 			buf.writeString(" (from ")
 			buf.writeString(src.File)
@@ -124,6 +133,96 @@ func (h *textHandler) HandleFormat(_ context.Context, record *slog.Record, fmtAr
 	return err
 }
 
+// processStart approximates the start time of the process.
+var processStart = time.Now()
+
+type timeMode int
+
+const (
+	timeAbsolute timeMode = iota
+	timeSinceStart
+	timeDelta
+	timeSinceOrigin
+)
+
+// deltaState holds the time of the previous record, shared by a handler and the handlers derived from it.
+type deltaState struct {
+	sync.Mutex
+	prev time.Time
+}
+
+// since returns the time elapsed since the previous record, and makes t the time of the previous record.
+func (d *deltaState) since(t time.Time) time.Duration {
+	d.Lock()
+	defer d.Unlock()
+	var elapsed time.Duration
+	if !d.prev.IsZero() {
+		elapsed = t.Sub(d.prev)
+	}
+	d.prev = t
+	return elapsed
+}
+
+// writeTime writes the time of a record according to the time mode of the handler.
+func (h *textHandler) writeTime(ctx context.Context, t time.Time, buf *bytesBuf) {
+	var elapsed time.Duration
+	switch h.timeMode {
+	case timeSinceStart:
+		elapsed = t.Sub(processStart)
+	case timeDelta:
+		elapsed = h.delta.since(t)
+	case timeSinceOrigin:
+		origin, ok := internal.TimeOrigin(ctx)
+		if !ok {
+			origin = processStart
+		}
+		elapsed = t.Sub(origin)
+	default:
+		if h.location != nil {
+			t = t.In(h.location)
+		}
+		*buf = t.AppendFormat(*buf, h.timeFormat)
+		return
+	}
+	_, _ = fmt.Fprintf(buf, "%+9.3fs", elapsed.Seconds())
+}
+
+// Flush flushes the output writer if it has a Flush() error method, like a [bufio.Writer].
+func (h *textHandler) Flush(context.Context) error {
+	if f, ok := h.writer().(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close closes the output writer if the handler was created with the [CloseOutput] option, and the writer is
+// an [io.Closer].
+func (h *textHandler) Close() error {
+	if h.closer == nil {
+		return nil
+	}
+	h.closer.once.Do(func() {
+		if c, ok := h.writer().(io.Closer); ok {
+			h.closer.err = c.Close()
+		}
+	})
+	return h.closer.err
+}
+
+// outputCloser closes the output of a handler and the handlers derived from it once.
+type outputCloser struct {
+	once sync.Once
+	err  error
+}
+
+// writer returns the writer given to the Output option, or the LevelWriter given to the LevelOutput option.
+func (h *textHandler) writer() any {
+	if a, ok := h.out.(allLevelsWriter); ok {
+		return a.out
+	}
+	return h.out
+}
+
 func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	h2 := *h
 	h2.attrs = append(h2.attrs, attrs...)
@@ -144,9 +243,15 @@ type textHandler struct {
 	groups          []string
 	out             LevelWriter
 	includeSource   bool
+	color           bool
+	location        *time.Location
+	timeMode        timeMode
+	delta           *deltaState
+	closer          *outputCloser
 }
 
 func addAttr(a slog.Attr, buf *bytesBuf) {
+	a.Value = a.Value.Resolve()
 	if a.Value.Kind() == slog.KindGroup {
 		addGroup(a.Key, a.Value.Group(), buf)
 	} else {
@@ -171,6 +276,7 @@ func addGroup(name string, attrs []slog.Attr, buf *bytesBuf) {
 		return
 	case 1:
 		a0 := attrs[0]
+		a0.Value = a0.Value.Resolve()
 		if a0.Value.Kind() == slog.KindGroup {
 			// Stand-alone top-level group. Embed it directly into the name.
 			buf.writeString(name)
@@ -191,32 +297,36 @@ func (h *textHandler) Enabled(ctx context.Context, level slog.Level) bool {
 	return h.levelEnabler(ctx, level)
 }
 
-// levelString writes the log level as a string to buf, padded to 6 characters.
-func levelString(l slog.Level, buf *bytesBuf) {
-	str := func(base string, val slog.Level) {
-		var n int
-		if val == 0 {
-			buf.writeString(base)
-			n = len(base)
-		} else {
-			n, _ = fmt.Fprintf(buf, "%s%+d", base, val)
-		}
+// levelString writes the log level as a string to buf, padded to one character more than the longest level
+// name. The name of the level is enclosed in ANSI color escape sequences when color is true.
+func levelString(l slog.Level, color bool, buf *bytesBuf) {
+	name := internal.LevelName(l)
+	if color {
+		buf.writeString(levelColor(l))
+		buf.writeString(name)
+		buf.writeString("\x1b[0m")
+	} else {
+		buf.writeString(name)
+	}
+	buf.writeByte(' ')
+	for i := internal.MaxLevelNameLen(); i > len(name); i-- {
 		buf.writeByte(' ')
-		for i := 5; i > n; i-- {
-			buf.writeByte(' ')
-		}
 	}
+}
+
+// levelColor returns the ANSI escape sequence that sets the color of a level.
+func levelColor(l slog.Level) string {
 	switch {
 	case l < slog.LevelDebug:
-		str("TRACE", l-slog.LevelDebug+4)
+		return "\x1b[90m" // bright black
 	case l < slog.LevelInfo:
-		str("DEBUG", l-slog.LevelDebug)
+		return "\x1b[36m" // cyan
 	case l < slog.LevelWarn:
-		str("INFO", l-slog.LevelInfo)
+		return "\x1b[32m" // green
 	case l < slog.LevelError:
-		str("WARN", l-slog.LevelWarn)
+		return "\x1b[33m" // yellow
 	default:
-		str("ERROR", l-slog.LevelError)
+		return "\x1b[31m" // red
 	}
 }
 
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/telepresenceio/clog/internal"
+)
+
+func TestTimeSinceStart(t *testing.T) {
+	var out bytes.Buffer
+	h := NewText(TimeSinceStart(), EnabledLevel(slog.LevelInfo), Output(&out))
+	r := slog.NewRecord(processStart.Add(1500*time.Millisecond), slog.LevelInfo, "started", 0)
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "   +1.500s INFO  started\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRegisteredLevel(t *testing.T) {
+	t.Cleanup(internal.ResetLevels)
+	if err := internal.RegisterLevel("notice", slog.LevelInfo+2); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	h := NewText(TimeFormat(""), EnabledLevel(slog.LevelInfo), Output(&out))
+	ctx := context.Background()
+	for _, l := range []slog.Level{slog.LevelInfo, slog.LevelInfo + 2, slog.LevelInfo + 3} {
+		_ = h.Handle(ctx, slog.NewRecord(time.Time{}, l, "msg", 0))
+	}
+	want := "INFO   msg\nNOTICE msg\nNOTICE+1 msg\n"
+	if got := out.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	var levels []slog.Level
+	for r, err := range ParseText(&out, ParseTimeFormat("")) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Message != "msg" {
+			t.Errorf("unexpected message %q", r.Message)
+		}
+		levels = append(levels, r.Level)
+	}
+	if len(levels) != 3 || levels[1] != slog.LevelInfo+2 || levels[2] != slog.LevelInfo+3 {
+		t.Errorf("unexpected levels %v", levels)
+	}
+}
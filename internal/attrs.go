@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// AttrState holds the attributes and groups added to a handler using WithAttrs and WithGroup, for handlers
+// that flatten attributes when the record is handled.
+type AttrState struct {
+	groups []string
+	attrs  []groupedAttr
+}
+
+// groupedAttr is an attribute along with the number of groups that were added before it.
+type groupedAttr struct {
+	depth int
+	attr  slog.Attr
+}
+
+// WithAttrs returns a copy of the state with the attributes added, in the groups of the state.
+func (s AttrState) WithAttrs(attrs []slog.Attr) AttrState {
+	s.attrs = slices.Clip(s.attrs)
+	for _, a := range attrs {
+		s.attrs = append(s.attrs, groupedAttr{depth: len(s.groups), attr: a})
+	}
+	return s
+}
+
+// WithGroup returns a copy of the state with the group added.
+func (s AttrState) WithGroup(name string) AttrState {
+	s.groups = append(slices.Clip(s.groups), name)
+	return s
+}
+
+// Groups returns the groups of the state. The returned slice must not be modified.
+func (s AttrState) Groups() []string {
+	return s.groups
+}
+
+// Message returns the message prefixed by the groups of the state, e.g. "group/subgroup: message", like the
+// text handler writes it.
+func (s AttrState) Message(msg string) string {
+	if len(s.groups) == 0 {
+		return msg
+	}
+	return strings.Join(s.groups, "/") + ": " + msg
+}
+
+// Walk calls f for each non-group attribute of the state followed by each non-group attribute of the record.
+// The path holds the keys of the enclosing groups, starting with the groups of the state that were added
+// before the attribute, followed by the key of the attribute. The attributes of the record are in all groups
+// of the state. Values are resolved, and empty attributes, empty groups, and the keys of inline groups are
+// skipped. The path is reused between calls, so f must copy it if it needs to retain it.
+func (s AttrState) Walk(record *slog.Record, f func(path []string, v slog.Value)) {
+	path := make([]string, 0, len(s.groups)+8)
+	for _, ga := range s.attrs {
+		walkAttr(append(path[:0], s.groups[:ga.depth]...), ga.attr, f)
+	}
+	if record != nil {
+		path = append(path[:0], s.groups...)
+		record.Attrs(func(a slog.Attr) bool {
+			walkAttr(path, a, f)
+			return true
+		})
+	}
+}
+
+func walkAttr(path []string, a slog.Attr, f func([]string, slog.Value)) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			path = append(path, a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			walkAttr(path, ga, f)
+		}
+		return
+	}
+	f(append(path, a.Key), a.Value)
+}
+
+// ResolveAttr returns the attribute with its value, and the values of the attributes of its groups,
+// resolved, so that the attribute no longer depends on [slog.LogValuer] values.
+func ResolveAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		resolved := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			resolved[i] = ResolveAttr(ga)
+		}
+		a.Value = slog.GroupValue(resolved...)
+	}
+	return a
+}
@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LevelConfig holds the level options of the handlers in the handler subpackages, which embed it in their
+// configuration. The zero value enables [slog.LevelInfo] and above.
+type LevelConfig struct {
+	enabler func(context.Context, slog.Level) bool
+}
+
+// SetLevel makes the configuration enable the level and all levels above it.
+func (c *LevelConfig) SetLevel(level slog.Level) {
+	c.enabler = func(_ context.Context, l slog.Level) bool { return l >= level }
+}
+
+// SetEnabler makes the configuration use enabler to determine if a level is enabled.
+func (c *LevelConfig) SetEnabler(enabler func(context.Context, slog.Level) bool) {
+	c.enabler = enabler
+}
+
+// Enabled reports whether the configuration enables the level.
+func (c *LevelConfig) Enabled(ctx context.Context, level slog.Level) bool {
+	if c.enabler == nil {
+		return level >= slog.LevelInfo
+	}
+	return c.enabler(ctx, level)
+}
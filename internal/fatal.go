@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LifecycleTimeout is the deadline used when the handlers of a logger are flushed or closed using a context
+// that has no deadline.
+const LifecycleTimeout = 5 * time.Second
+
+// WithLifecycleDeadline returns ctx with a LifecycleTimeout deadline unless it already has a deadline.
+func WithLifecycleDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, LifecycleTimeout)
+}
+
+// Fatal is called by the Fatal functions once the record has been logged. It flushes the handlers of the
+// context logger, and then calls the exit function of the context with exit code 1.
+func Fatal(ctx context.Context) {
+	flushLogger(ctx)
+	Exit(ctx, 1)
+}
+
+// Panic is called by the Panic functions once the record has been logged. It flushes the handlers of the
+// context logger, and then panics with the message.
+func Panic(ctx context.Context, msg string) {
+	flushLogger(ctx)
+	panic(msg)
+}
+
+// PanicMessage returns the message of a record logged using Log with the given arguments.
+func PanicMessage(args []any) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return fmt.Sprint(args[0])
+}
+
+// flushLogger flushes the handlers of the context logger. The flushing isn't affected by the cancellation or
+// the deadline of ctx.
+func flushLogger(ctx context.Context) {
+	ctx, cancel := WithLifecycleDeadline(context.WithoutCancel(ctx))
+	defer cancel()
+	_ = Flush(ctx, Logger(ctx).Handler())
+}
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// Flush flushes h and the handlers that it wraps, outermost first. Handlers without a
+// Flush(context.Context) error method are skipped.
+func Flush(ctx context.Context, h slog.Handler) error {
+	return walk(h, func(h slog.Handler) error {
+		if f, ok := h.(interface{ Flush(context.Context) error }); ok {
+			return f.Flush(ctx)
+		}
+		return nil
+	})
+}
+
+// Close flushes h and the handlers that it wraps, and then closes them, outermost first. Handlers with a
+// CloseContext(context.Context) error method are closed using it, so that ctx limits the time spent closing
+// them, and handlers with a Close() error method are closed using it. Other handlers are skipped. The handlers
+// are closed before Close returns, also when the flushing fails.
+func Close(ctx context.Context, h slog.Handler) error {
+	err := Flush(ctx, h)
+	return errors.Join(err, walk(h, func(h slog.Handler) error {
+		switch c := h.(type) {
+		case interface{ CloseContext(context.Context) error }:
+			return c.CloseContext(ctx)
+		case interface{ Close() error }:
+			return c.Close()
+		}
+		return nil
+	}))
+}
+
+// walk calls f for h and for all handlers wrapped by it, outermost first, and joins the returned errors.
+// A handler wraps other handlers if it has an Unwrap() slog.Handler or an Unwrap() []slog.Handler method.
+func walk(h slog.Handler, f func(slog.Handler) error) error {
+	errs := []error{f(h)}
+	switch u := h.(type) {
+	case interface{ Unwrap() slog.Handler }:
+		errs = append(errs, walk(u.Unwrap(), f))
+	case interface{ Unwrap() []slog.Handler }:
+		for _, w := range u.Unwrap() {
+			errs = append(errs, walk(w, f))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// NamedLevel is a level with a name.
+type NamedLevel struct {
+	Name  string
+	Level slog.Level
+}
+
+// levelSet is a snapshot of the named levels.
+type levelSet struct {
+	// levels holds the named levels, sorted by level.
+	levels []NamedLevel
+
+	// names holds the names of the levels in order of increasing verbosity, i.e. decreasing level.
+	names []string
+}
+
+func newLevelSet(levels []NamedLevel) *levelSet {
+	slices.SortFunc(levels, func(a, b NamedLevel) int { return int(a.Level - b.Level) })
+	names := make([]string, len(levels))
+	for i, nl := range levels {
+		names[len(levels)-1-i] = nl.Name
+	}
+	return &levelSet{levels: levels, names: names}
+}
+
+var (
+	levelsMu sync.Mutex
+
+	// levels is replaced, never modified, so that it can be read without locking.
+	levels atomic.Pointer[levelSet]
+)
+
+func init() {
+	ResetLevels()
+}
+
+// builtinLevels are the levels that are named before any level is registered. Their names are parsed even
+// when the levels have been renamed.
+var builtinLevels = []NamedLevel{
+	{"TRACE", slog.LevelDebug - 4},
+	{"DEBUG", slog.LevelDebug},
+	{"INFO", slog.LevelInfo},
+	{"WARN", slog.LevelWarn},
+	{"ERROR", slog.LevelError},
+}
+
+// ResetLevels removes all registered levels, leaving only the built-in TRACE, DEBUG, INFO, WARN, and ERROR levels.
+func ResetLevels() {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	levels.Store(newLevelSet(slices.Clone(builtinLevels)))
+}
+
+// RegisterLevel gives a name to a level. The name is converted to upper case. A level has only one name, so
+// a previous name of the level is replaced, and a name that is given to another level is moved from its
+// previous level.
+func RegisterLevel(name string, level slog.Level) error {
+	name = strings.ToUpper(name)
+	if name == "" || strings.ContainsAny(name, "+- \t\r\n") {
+		return fmt.Errorf("invalid level name %q", name)
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return fmt.Errorf("invalid level name %q", name)
+	}
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	old := levels.Load().levels
+	nls := make([]NamedLevel, 0, len(old)+1)
+	for _, nl := range old {
+		if nl.Name != name && nl.Level != level {
+			nls = append(nls, nl)
+		}
+	}
+	levels.Store(newLevelSet(append(nls, NamedLevel{Name: name, Level: level})))
+	return nil
+}
+
+// Levels returns the named levels, sorted by level. The returned slice must not be modified.
+func Levels() []NamedLevel {
+	return levels.Load().levels
+}
+
+// LevelNames returns the names of the named levels in order of increasing verbosity. The returned slice must
+// not be modified.
+func LevelNames() []string {
+	return levels.Load().names
+}
+
+// LevelName returns the name of the level, using an offset from the closest lower named level when the
+// level has no name of its own, e.g. "DEBUG+1", or "ERROR+4". Levels below the lowest named level use
+// a negative offset from that level, e.g. "TRACE-1".
+func LevelName(l slog.Level) string {
+	nls := Levels()
+	nl := nls[0]
+	for _, n := range nls[1:] {
+		if n.Level > l {
+			break
+		}
+		nl = n
+	}
+	if l == nl.Level {
+		return nl.Name
+	}
+	return fmt.Sprintf("%s%+d", nl.Name, l-nl.Level)
+}
+
+// MaxLevelNameLen returns the length of the longest level name.
+func MaxLevelNameLen() int {
+	n := 0
+	for _, nl := range Levels() {
+		n = max(n, len(nl.Name))
+	}
+	return n
+}
+
+// ParseLevelName parses a name produced by LevelName, using case-insensitive comparison. The names of the
+// built-in levels are accepted also after the levels have been renamed, unless the name has been given to
+// another level.
+func ParseLevelName(s string) (slog.Level, bool) {
+	name, offset := s, ""
+	if i := strings.IndexAny(s, "+-"); i > 0 {
+		name, offset = s[:i], s[i:]
+	}
+	isName := func(nl NamedLevel) bool { return strings.EqualFold(nl.Name, name) }
+	nls := Levels()
+	i := slices.IndexFunc(nls, isName)
+	if i < 0 {
+		if nls, i = builtinLevels, slices.IndexFunc(builtinLevels, isName); i < 0 {
+			return 0, false
+		}
+	}
+	level := nls[i].Level
+	if offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil {
+			return 0, false
+		}
+		level += slog.Level(n)
+	}
+	return level, true
+}
@@ -2,8 +2,10 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 	"runtime"
 	"time"
 )
@@ -13,6 +15,74 @@ type Level = slog.Level
 // TimeNow is for test purposes only.
 var TimeNow = time.Now
 
+// Clock provides the time used for log records.
+type Clock interface {
+	Now() time.Time
+}
+
+type clockKey struct{}
+
+// WithClock assigns the clock to a child context which is returned.
+func WithClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockKey{}, clock)
+}
+
+// Now returns the time from the context clock, or from [TimeNow] if no clock is set.
+func Now(ctx context.Context) time.Time {
+	if c, ok := ctx.Value(clockKey{}).(Clock); ok {
+		return c.Now()
+	}
+	return TimeNow()
+}
+
+type timeOriginKey struct{}
+
+// WithTimeOrigin assigns the time origin to a child context which is returned.
+func WithTimeOrigin(ctx context.Context, origin time.Time) context.Context {
+	return context.WithValue(ctx, timeOriginKey{}, origin)
+}
+
+// TimeOrigin returns the time origin of the context, and true if one is set.
+func TimeOrigin(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(timeOriginKey{}).(time.Time)
+	return t, ok
+}
+
+// ErrIdle is returned by a reader, such as the reader of a followed file, that has no more data yet but
+// may have more later. The parsers of the handler package complete their pending record when they get it,
+// and then continue reading.
+var ErrIdle = errors.New("no more data yet")
+
+type sourceKey struct{}
+
+// WithSource assigns a source to a child context which is returned. The text handler uses it for records
+// that lack a program counter, such as records parsed from its output.
+func WithSource(ctx context.Context, src *slog.Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, src)
+}
+
+// Source returns the source of the context, or nil if none is set.
+func Source(ctx context.Context) *slog.Source {
+	src, _ := ctx.Value(sourceKey{}).(*slog.Source)
+	return src
+}
+
+type exitFuncKey struct{}
+
+// WithExitFunc assigns the exit function to a child context which is returned.
+func WithExitFunc(ctx context.Context, exit func(code int)) context.Context {
+	return context.WithValue(ctx, exitFuncKey{}, exit)
+}
+
+// Exit calls the exit function of the context, or [os.Exit] if none is set.
+func Exit(ctx context.Context, code int) {
+	if exit, ok := ctx.Value(exitFuncKey{}).(func(int)); ok {
+		exit(code)
+		return
+	}
+	os.Exit(code)
+}
+
 type FormatHandler interface {
 	HandleFormat(context.Context, *slog.Record, []any) error
 }
@@ -25,7 +95,7 @@ func Log(ctx context.Context, level slog.Level, args ...any) {
 		if !ok {
 			msg = fmt.Sprint(a0)
 		}
-		r := newRecord(level, msg)
+		r := newRecord(ctx, level, msg)
 		r.Add(args[1:]...)
 		_ = h.Handle(ctx, r)
 	}
@@ -34,7 +104,7 @@ func Log(ctx context.Context, level slog.Level, args ...any) {
 func LogAttrs(ctx context.Context, level slog.Level, message string, attrs ...slog.Attr) {
 	h := Logger(ctx).Handler()
 	if h.Enabled(ctx, level) {
-		r := newRecord(level, message)
+		r := newRecord(ctx, level, message)
 		r.AddAttrs(attrs...)
 		_ = h.Handle(ctx, r)
 	}
@@ -47,19 +117,19 @@ func Logf(ctx context.Context, level slog.Level, format string, args ...any) {
 		if fh, ok := h.(FormatHandler); ok {
 			// Defer formatting so that the handler's internal buffer can be used instead of
 			// having fmt.Sprintf allocating one here.
-			r = newRecord(level, format)
+			r = newRecord(ctx, level, format)
 			_ = fh.HandleFormat(ctx, &r, args)
 		} else {
 			// This is unfortunate, but slog does not provide a way to defer the creation of the actual message.
-			_ = h.Handle(ctx, newRecord(level, fmt.Sprintf(format, args...)))
+			_ = h.Handle(ctx, newRecord(ctx, level, fmt.Sprintf(format, args...)))
 		}
 	}
 }
 
-func newRecord(level Level, msg string) slog.Record {
+func newRecord(ctx context.Context, level Level, msg string) slog.Record {
 	var pcs [1]uintptr
 	runtime.Callers(4, pcs[:]) // skip [Callers, newRecord, log, caller-of-log]
-	return slog.NewRecord(TimeNow(), level, msg, pcs[0])
+	return slog.NewRecord(Now(ctx), level, msg, pcs[0])
 }
 
 type loggerKey struct{}
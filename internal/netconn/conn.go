@@ -0,0 +1,86 @@
+// Package netconn provides a network connection that reconnects when a write fails.
+package netconn
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the timeout used by the handlers when dialing and writing, unless one is given.
+const DefaultTimeout = 5 * time.Second
+
+// Conn is a connection that is dialed on first use and redialed after a failed write. It is safe
+// for concurrent use, and each call to Write is atomic.
+type Conn struct {
+	mu      sync.Mutex
+	network string
+	addr    string
+	timeout time.Duration
+	conn    net.Conn
+	closed  bool
+}
+
+// Dial returns a Conn for the given network and address, dialed using the given timeout.
+func Dial(network, addr string, timeout time.Duration) (*Conn, error) {
+	c := &Conn{network: network, addr: addr, timeout: timeout}
+	if err := c.dial(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// IsStream returns true if the network of the connection is stream oriented.
+func (c *Conn) IsStream() bool {
+	switch c.network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return false
+	default:
+		return true
+	}
+}
+
+func (c *Conn) dial() (err error) {
+	c.conn, err = net.DialTimeout(c.network, c.addr, c.timeout)
+	return err
+}
+
+// Write writes p to the connection. If the write fails, the connection is redialed and the write is retried once.
+func (c *Conn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	if c.conn != nil {
+		n, err := c.write(p)
+		if err == nil {
+			return n, nil
+		}
+		_ = c.conn.Close()
+		c.conn = nil
+	}
+	if err := c.dial(); err != nil {
+		return 0, err
+	}
+	return c.write(p)
+}
+
+func (c *Conn) write(p []byte) (int, error) {
+	if c.timeout > 0 {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	}
+	return c.conn.Write(p)
+}
+
+// Close closes the connection. Subsequent writes fail with [net.ErrClosed].
+func (c *Conn) Close() (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.conn != nil {
+		err = c.conn.Close()
+		c.conn = nil
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// Tree is shared by a context and all its children. It holds the dynamic root level of the
+// tree and values that must be scoped to it.
+type Tree struct {
+	level  atomic.Int64
+	values sync.Map
+}
+
+type treeKey struct{}
+
+// WithTree assigns a new Tree with the given level to a child context which is returned.
+func WithTree(ctx context.Context, level slog.Level) context.Context {
+	t := &Tree{}
+	t.level.Store(int64(level))
+	return context.WithValue(ctx, treeKey{}, t)
+}
+
+// AttachTree assigns an existing Tree to a child context which is returned.
+func AttachTree(ctx context.Context, t *Tree) context.Context {
+	return context.WithValue(ctx, treeKey{}, t)
+}
+
+// TreeFrom returns the Tree of the context, or nil if no tree has been assigned.
+func TreeFrom(ctx context.Context) *Tree {
+	t, _ := ctx.Value(treeKey{}).(*Tree)
+	return t
+}
+
+// Level returns the root level of the tree.
+func (t *Tree) Level() slog.Level {
+	return slog.Level(t.level.Load())
+}
+
+// SetLevel sets the root level of the tree and returns true if it was changed.
+func (t *Tree) SetLevel(level slog.Level) bool {
+	oldLevel := t.level.Load()
+	newLevel := int64(level)
+	if newLevel != oldLevel {
+		return t.level.CompareAndSwap(oldLevel, newLevel)
+	}
+	return false
+}
+
+// Value returns the value stored in the tree for the given key. If no value exists, one is
+// created by calling create and stored.
+func (t *Tree) Value(key any, create func() any) any {
+	if v, ok := t.values.Load(key); ok {
+		return v
+	}
+	v, _ := t.values.LoadOrStore(key, create())
+	return v
+}
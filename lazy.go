@@ -0,0 +1,39 @@
+package clog
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// lazyValue is a [slog.LogValuer] that calls its function at most once.
+type lazyValue struct {
+	once sync.Once
+	fn   func() slog.Value
+	v    slog.Value
+}
+
+func (l *lazyValue) LogValue() slog.Value {
+	l.once.Do(func() {
+		l.v = l.fn()
+		l.fn = nil
+	})
+	return l.v
+}
+
+// String makes the value usable as an argument to functions such as [Infof].
+func (l *lazyValue) String() string {
+	return l.LogValue().String()
+}
+
+// Lazy returns an attribute with a value that is computed by calling fn when, and only when, a handler
+// resolves it. Functions in this package never create a record, and hence never resolve its attributes,
+// unless the context logger is enabled for the level of the record. The function is called at most once,
+// even when the record is handled by several handlers.
+func Lazy(key string, fn func() any) slog.Attr {
+	return slog.Any(key, &lazyValue{fn: func() slog.Value { return slog.AnyValue(fn()) }})
+}
+
+// LazyGroup is like [Lazy], but for a group of attributes.
+func LazyGroup(key string, fn func() []slog.Attr) slog.Attr {
+	return slog.Any(key, &lazyValue{fn: func() slog.Value { return slog.GroupValue(fn()...) }})
+}
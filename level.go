@@ -2,10 +2,9 @@ package clog
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
-	"strings"
-	"sync/atomic"
+
+	"github.com/telepresenceio/clog/internal"
 )
 
 // LevelTrace is the most verbose log level.
@@ -17,23 +16,29 @@ const LevelTrace = slog.LevelDebug - 4
 // shouldn't otherwise be used. Use `slog.Level` whenever possible.
 type LevelWithTrace slog.Level
 
-// LevelStrings is the list of log level strings in order of increasing verbosity.
-var LevelStrings = []string{
-	"ERROR", "WARN", "INFO", "DEBUG", "TRACE",
+// LevelStrings returns the names of the registered levels in order of increasing verbosity. Without any
+// levels added by [RegisterLevel], they are "ERROR", "WARN", "INFO", "DEBUG", and "TRACE". The returned slice
+// must not be modified.
+func LevelStrings() []string {
+	return internal.LevelNames()
 }
 
-func (l LevelWithTrace) String() (s string) {
-	sl := slog.Level(l)
-	diff := sl - slog.LevelDebug
-	switch {
-	case diff == -4:
-		s = "TRACE"
-	case diff < 0:
-		s = fmt.Sprintf("TRACE%+d", diff)
-	default:
-		s = sl.String()
-	}
-	return s
+// RegisterLevel adds a named level to the level registry, or renames a registered level. The name is converted
+// to upper case, and it must not be a number or contain '+', '-', or white space. A level has only one name, so
+// registering a new name for a built-in level replaces its built-in name in the output. The built-in name is
+// still accepted by [ParseLevel] unless it's given to another level.
+//
+// The names of the registry are used by [ParseLevel], [LevelWithTrace], and [LevelStrings], by the text handler
+// of the handler package, which pads its level column to fit the longest name, and by its ReplaceLevelName
+// function, which makes JSON output use the names. A level without a name is written as an offset from the
+// closest lower named level, e.g. "INFO+2". It is safe to register levels concurrently with logging, but
+// records logged before a level is registered are written using the previous names.
+func RegisterLevel(name string, level slog.Level) error {
+	return internal.RegisterLevel(name, level)
+}
+
+func (l LevelWithTrace) String() string {
+	return internal.LevelName(slog.Level(l))
 }
 
 // MarshalText implements encoding.TextMarshaler.
@@ -42,24 +47,24 @@ func (l LevelWithTrace) MarshalText() ([]byte, error) {
 }
 
 // UnmarshalText will unmarshal a log level string into a Level.
-// An error is returned if the string is not one of "TRACE", "DEBUG", "INFO", "WARN", or "ERROR"
-// using case-insensitive comparison.
+// An error is returned if the string is not the name of a registered level, such as "TRACE", "DEBUG", "INFO",
+// "WARN", or "ERROR", optionally followed by an offset such as "+2", using case-insensitive comparison.
 //goland:noinspection GoMixedReceiverTypes
 func (l *LevelWithTrace) UnmarshalText(value []byte) error {
+	if sl, ok := internal.ParseLevelName(string(value)); ok {
+		*l = LevelWithTrace(sl)
+		return nil
+	}
 	var sl slog.Level
 	err := sl.UnmarshalText(value)
-	switch {
-	case err == nil:
+	if err == nil {
 		*l = LevelWithTrace(sl)
-	case strings.EqualFold(string(value), "TRACE"):
-		*l = LevelWithTrace(LevelTrace)
-		err = nil
 	}
 	return err
 }
 
-// ParseLevel parses a log level string into a Level. It panics if the lowercased version of
-// the string is not one of "trace", "debug", "info", "warn", "warning", or "error".
+// ParseLevel parses a log level string into a Level. An error is returned if the string is not the name of a
+// registered level, optionally followed by an offset, using case-insensitive comparison. See [RegisterLevel].
 func ParseLevel(s string) (slog.Level, error) {
 	var l LevelWithTrace
 	err := l.UnmarshalText([]byte(s))
@@ -75,20 +80,17 @@ func MustParseLevel(s string) slog.Level {
 	return l
 }
 
-type treeLevelKey struct{}
-
 // WithTreeLevel assigns the log level root to a child context which is returned. Children
 // of this context will inherit the level.
 func WithTreeLevel(ctx context.Context, level slog.Level) context.Context {
-	lv := int64(level)
-	return context.WithValue(ctx, treeLevelKey{}, &lv)
+	return internal.WithTree(ctx, level)
 }
 
 // TreeEnabled returns true if the root [slog.Level] is enabled for the given context.
 // This function is suitable as an argument to a [handler.LevelEnabler] option.
 func TreeEnabled(ctx context.Context, level slog.Level) bool {
-	if lvp, ok := ctx.Value(treeLevelKey{}).(*int64); ok {
-		return int64(level) >= atomic.LoadInt64(lvp)
+	if t := internal.TreeFrom(ctx); t != nil {
+		return level >= t.Level()
 	}
 	return false
 }
@@ -97,12 +99,8 @@ func TreeEnabled(ctx context.Context, level slog.Level) bool {
 // might be the provided context itself or any parent of that context. Any children of context holding
 // the root level is affected by this change.
 func SetTreeLevel(ctx context.Context, level slog.Level) bool {
-	if lvp, ok := ctx.Value(treeLevelKey{}).(*int64); ok {
-		oldLevel := atomic.LoadInt64(lvp)
-		newLevel := int64(level)
-		if newLevel != oldLevel {
-			return atomic.CompareAndSwapInt64(lvp, oldLevel, newLevel)
-		}
+	if t := internal.TreeFrom(ctx); t != nil {
+		return t.SetLevel(level)
 	}
 	return false
 }
@@ -0,0 +1,57 @@
+package clog
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/telepresenceio/clog/internal"
+)
+
+// Flush flushes the handler of the context logger and all handlers that it wraps, so that buffered output is
+// written. See [handler.Flush]. A deadline of 5 seconds is used unless ctx has a deadline.
+func Flush(ctx context.Context) error {
+	ctx, cancel := internal.WithLifecycleDeadline(ctx)
+	defer cancel()
+	return internal.Flush(ctx, internal.Logger(ctx).Handler())
+}
+
+// Close flushes and closes the handler of the context logger and all handlers that it wraps, so that buffered
+// output is written and connections and files are closed. See [handler.Close]. The context logger must not be
+// used once it has been closed. A deadline of 5 seconds is used for the flushing and the closing unless ctx has a
+// deadline.
+func Close(ctx context.Context) error {
+	ctx, cancel := internal.WithLifecycleDeadline(ctx)
+	defer cancel()
+	return internal.Close(ctx, internal.Logger(ctx).Handler())
+}
+
+// CloseOnSignal makes the process [Close] the context logger when it receives one of the given signals, or
+// SIGINT or SIGTERM if no signals are given. CloseOnSignal then stops handling the signals and raises the signal
+// again, so that the process terminates as it would have without CloseOnSignal, or, when other code has asked
+// to be notified of the signal using [signal.Notify], so that the other code receives it. The returned function
+// stops the handling of the signals.
+func CloseOnSignal(ctx context.Context, sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		select {
+		case sig := <-ch:
+			_ = Close(context.WithoutCancel(ctx))
+			signal.Stop(ch)
+			if p, err := os.FindProcess(os.Getpid()); err != nil || p.Signal(sig) != nil {
+				internal.Exit(ctx, 1)
+			}
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
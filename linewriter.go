@@ -0,0 +1,135 @@
+package clog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/telepresenceio/clog/internal"
+)
+
+// LineOption configures a writer created by [LineWriter].
+type LineOption func(*lineWriter)
+
+// defaultMaxLineLength is the default maximum length of a line logged by a [LineWriter].
+const defaultMaxLineLength = 16384
+
+// MaxLineLength sets the maximum length, in bytes, of a logged line. Longer lines are split into
+// several records. The default is 16384, which is also used when n is zero or negative.
+func MaxLineLength(n int) LineOption {
+	return func(w *lineWriter) {
+		if n <= 0 {
+			n = defaultMaxLineLength
+		}
+		w.maxLen = n
+	}
+}
+
+// LineClassifier sets a function that determines the level of each line. When it returns true, the
+// returned level and line are logged instead of the default level and the original line.
+// [SplitLevelPrefix] is suitable as a classifier.
+func LineClassifier(classify func(line string) (slog.Level, string, bool)) LineOption {
+	return func(w *lineWriter) {
+		w.classify = classify
+	}
+}
+
+// LineAttrs adds attributes, such as slog.String("stream", "stderr"), to each logged line.
+func LineAttrs(attrs ...slog.Attr) LineOption {
+	return func(w *lineWriter) {
+		w.attrs = append(w.attrs, attrs...)
+	}
+}
+
+type lineWriter struct {
+	sync.Mutex
+	ctx      context.Context
+	h        slog.Handler
+	level    slog.Level
+	maxLen   int
+	classify func(string) (slog.Level, string, bool)
+	attrs    []slog.Attr
+	buf      []byte
+	closed   bool
+}
+
+// LineWriter returns a writer that splits what's written to it into lines and logs each line as a record
+// on the context logger at the given level. Partial lines are buffered until they are completed by a
+// newline, they exceed the maximum line length, or the writer is closed. Trailing carriage returns are
+// removed and empty lines are not logged. The writer is safe for concurrent use, which makes it suitable
+// as the Stdout or Stderr of an [os/exec.Cmd].
+func LineWriter(ctx context.Context, level slog.Level, opts ...LineOption) io.WriteCloser {
+	w := &lineWriter{ctx: ctx, h: Logger(ctx).Handler(), level: level, maxLen: defaultMaxLineLength}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	w.buf = append(w.buf, p...)
+	for {
+		if i := bytes.IndexByte(w.buf, '\n'); i >= 0 && i <= w.maxLen {
+			w.logLine(w.buf[:i])
+			w.buf = w.buf[i+1:]
+			continue
+		}
+		if len(w.buf) <= w.maxLen {
+			break
+		}
+		// Overlong line. Split it, but not in the middle of a UTF-8 sequence.
+		n := w.maxLen
+		for n > 0 && !utf8.RuneStart(w.buf[n]) {
+			n--
+		}
+		if n == 0 {
+			n = w.maxLen
+		}
+		w.logLine(w.buf[:n])
+		w.buf = w.buf[n:]
+	}
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+// Close logs any buffered partial line.
+func (w *lineWriter) Close() error {
+	w.Lock()
+	defer w.Unlock()
+	if !w.closed {
+		w.closed = true
+		w.logLine(w.buf)
+		w.buf = nil
+	}
+	return nil
+}
+
+func (w *lineWriter) logLine(data []byte) {
+	data = bytes.TrimSuffix(data, []byte{'\r'})
+	if len(data) == 0 {
+		return
+	}
+	line := string(data)
+	level := w.level
+	if w.classify != nil {
+		if l, s, ok := w.classify(line); ok {
+			level, line = l, s
+		}
+	}
+	if w.h.Enabled(w.ctx, level) {
+		r := slog.NewRecord(internal.Now(w.ctx), level, line, 0)
+		r.AddAttrs(w.attrs...)
+		_ = w.h.Handle(w.ctx, r)
+	}
+}
@@ -0,0 +1,28 @@
+package clog_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/telepresenceio/clog"
+	"github.com/telepresenceio/clog/handler"
+)
+
+func TestMaxLineLength_nonPositive(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		var buf bytes.Buffer
+		ctx := clog.WithLogger(context.Background(), slog.New(handler.NewText(handler.Output(&buf), handler.TimeFormat(""), handler.EnabledLevel(slog.LevelInfo))))
+		w := clog.LineWriter(ctx, slog.LevelInfo, clog.MaxLineLength(n))
+		line := strings.Repeat("x", 100)
+		if _, err := w.Write([]byte(line + "\nrest")); err != nil {
+			t.Fatal(err)
+		}
+		_ = w.Close()
+		if got, want := buf.String(), "INFO  "+line+"\nINFO  rest\n"; got != want {
+			t.Errorf("MaxLineLength(%d): got %q, want %q", n, got, want)
+		}
+	}
+}
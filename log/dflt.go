@@ -1,49 +1,260 @@
+// Package log provides the functions of the clog package without a context argument. They use the default
+// logger of this package, which is [slog.Default] unless another logger is assigned using [SetDefault].
+//
+// The functions log using a context that holds a process-wide dynamic level, which is set by [SetLevel].
+// It is honored by handlers that use [clog.TreeEnabled] as their level enabler, and can be obtained using
+// [Context] when a context is needed by other functions of the clog package.
 package log
 
 import (
 	"context"
+	"fmt"
 	stdLog "log"
 	"log/slog"
+	"sync/atomic"
 
 	"github.com/telepresenceio/clog"
 	"github.com/telepresenceio/clog/internal"
 )
 
+// rootCtx holds the process-wide dynamic level.
+var rootCtx = internal.WithTree(context.Background(), slog.LevelInfo)
+
+// defaultCtx holds the context with the logger assigned by SetDefault, or nil if no logger is assigned.
+var defaultCtx atomic.Pointer[context.Context]
+
+func dfltCtx() context.Context {
+	if ctx := defaultCtx.Load(); ctx != nil {
+		return *ctx
+	}
+	return rootCtx
+}
+
+// Context returns the context used by the functions of this package. It holds the default logger and the
+// process-wide dynamic level.
+func Context() context.Context {
+	return dfltCtx()
+}
+
+// Debug is [clog.Debug] using the default logger.
+func Debug(args ...any) {
+	internal.Log(dfltCtx(), slog.LevelDebug, args...)
+}
+
+// DebugAttrs is [clog.DebugAttrs] using the default logger.
+func DebugAttrs(message string, attrs ...slog.Attr) {
+	internal.LogAttrs(dfltCtx(), slog.LevelDebug, message, attrs...)
+}
+
 // Debugf is [clog.Debugf] using the default logger.
 func Debugf(format string, args ...any) {
-	internal.Logf(context.Background(), slog.LevelDebug, format, args...)
+	internal.Logf(dfltCtx(), slog.LevelDebug, format, args...)
+}
+
+// Default returns the default logger. Like the functions of this package, the returned logger honors the
+// process-wide dynamic level when it's used with a context that has no level of its own.
+func Default() *slog.Logger {
+	l := internal.Logger(dfltCtx())
+	if _, ok := l.Handler().(*rootTreeHandler); ok {
+		return l
+	}
+	return slog.New(&rootTreeHandler{Handler: l.Handler()})
+}
+
+// Enabled reports whether the default logger emits records at the given level.
+func Enabled(level slog.Level) bool {
+	ctx := dfltCtx()
+	return internal.Logger(ctx).Enabled(ctx, level)
+}
+
+// Error is [clog.Error] using the default logger.
+func Error(args ...any) {
+	internal.Log(dfltCtx(), slog.LevelError, args...)
+}
+
+// ErrorAttrs is [clog.ErrorAttrs] using the default logger.
+func ErrorAttrs(message string, attrs ...slog.Attr) {
+	internal.LogAttrs(dfltCtx(), slog.LevelError, message, attrs...)
 }
 
 // Errorf is [clog.Errorf] using the default logger.
 func Errorf(format string, args ...any) {
-	internal.Logf(context.Background(), slog.LevelError, format, args...)
+	internal.Logf(dfltCtx(), slog.LevelError, format, args...)
 }
 
-func Enabled(level slog.Level) bool {
-	return slog.Default().Enabled(context.Background(), level)
+// Fatal is [clog.Fatal] using the default logger.
+func Fatal(args ...any) {
+	internal.Log(dfltCtx(), clog.LevelFatal, args...)
+	internal.Fatal(dfltCtx())
+}
+
+// FatalAttrs is [clog.FatalAttrs] using the default logger.
+func FatalAttrs(message string, attrs ...slog.Attr) {
+	internal.LogAttrs(dfltCtx(), clog.LevelFatal, message, attrs...)
+	internal.Fatal(dfltCtx())
+}
+
+// Fatalf is [clog.Fatalf] using the default logger.
+func Fatalf(format string, args ...any) {
+	internal.Logf(dfltCtx(), clog.LevelFatal, format, args...)
+	internal.Fatal(dfltCtx())
+}
+
+// Info is [clog.Info] using the default logger.
+func Info(args ...any) {
+	internal.Log(dfltCtx(), slog.LevelInfo, args...)
+}
+
+// InfoAttrs is [clog.InfoAttrs] using the default logger.
+func InfoAttrs(message string, attrs ...slog.Attr) {
+	internal.LogAttrs(dfltCtx(), slog.LevelInfo, message, attrs...)
 }
 
 // Infof is [clog.Infof] using the default logger.
 func Infof(format string, args ...any) {
-	internal.Logf(context.Background(), slog.LevelInfo, format, args...)
+	internal.Logf(dfltCtx(), slog.LevelInfo, format, args...)
+}
+
+// Level returns the process-wide dynamic level.
+func Level() slog.Level {
+	return internal.TreeFrom(rootCtx).Level()
+}
+
+// LevelEnabled returns true if the level is enabled by the process-wide dynamic level. It is the
+// equivalent of [clog.TreeEnabled].
+func LevelEnabled(level slog.Level) bool {
+	return clog.TreeEnabled(dfltCtx(), level)
+}
+
+// Log is [clog.Log] using the default logger.
+func Log(level slog.Level, args ...any) {
+	internal.Log(dfltCtx(), level, args...)
+}
+
+// LogAttrs is [clog.LogAttrs] using the default logger.
+func LogAttrs(level slog.Level, message string, attrs ...slog.Attr) {
+	internal.LogAttrs(dfltCtx(), level, message, attrs...)
 }
 
 // Logf is [clog.Logf] using the default logger.
 func Logf(level slog.Level, format string, args ...any) {
-	internal.Logf(context.Background(), level, format, args...)
+	internal.Logf(dfltCtx(), level, format, args...)
+}
+
+// Panic is [clog.Panic] using the default logger.
+func Panic(args ...any) {
+	internal.Log(dfltCtx(), clog.LevelPanic, args...)
+	internal.Panic(dfltCtx(), internal.PanicMessage(args))
+}
+
+// PanicAttrs is [clog.PanicAttrs] using the default logger.
+func PanicAttrs(message string, attrs ...slog.Attr) {
+	internal.LogAttrs(dfltCtx(), clog.LevelPanic, message, attrs...)
+	internal.Panic(dfltCtx(), message)
+}
+
+// Panicf is [clog.Panicf] using the default logger.
+func Panicf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	internal.LogAttrs(dfltCtx(), clog.LevelPanic, msg)
+	internal.Panic(dfltCtx(), msg)
+}
+
+// SetDefault makes l the default logger of this package. [slog.Default] is used when l is nil, which is
+// also the initial state. Unlike [slog.SetDefault], it doesn't affect the standard log package.
+func SetDefault(l *slog.Logger) {
+	if l == nil {
+		defaultCtx.Store(nil)
+		return
+	}
+	ctx := internal.WithLogger(rootCtx, l)
+	defaultCtx.Store(&ctx)
+}
+
+// SetLevel sets the process-wide dynamic level, and returns true if it was changed. The initial level
+// is [slog.LevelInfo].
+func SetLevel(level slog.Level) bool {
+	return internal.TreeFrom(rootCtx).SetLevel(level)
 }
 
 // StdLogger is [clog.StdLogger] using the default logger.
-func StdLogger(level slog.Level) *stdLog.Logger {
-	return clog.StdLogger(context.Background(), level)
+func StdLogger(level slog.Level, opts ...clog.StdLogOption) *stdLog.Logger {
+	return clog.StdLogger(dfltCtx(), level, opts...)
+}
+
+// Trace is [clog.Trace] using the default logger.
+func Trace(args ...any) {
+	internal.Log(dfltCtx(), clog.LevelTrace, args...)
+}
+
+// TraceAttrs is [clog.TraceAttrs] using the default logger.
+func TraceAttrs(message string, attrs ...slog.Attr) {
+	internal.LogAttrs(dfltCtx(), clog.LevelTrace, message, attrs...)
 }
 
 // Tracef is [clog.Tracef] using the default logger.
 func Tracef(format string, args ...any) {
-	internal.Logf(context.Background(), clog.LevelTrace, format, args...)
+	internal.Logf(dfltCtx(), clog.LevelTrace, format, args...)
+}
+
+// Warn is [clog.Warn] using the default logger.
+func Warn(args ...any) {
+	internal.Log(dfltCtx(), slog.LevelWarn, args...)
+}
+
+// WarnAttrs is [clog.WarnAttrs] using the default logger.
+func WarnAttrs(message string, attrs ...slog.Attr) {
+	internal.LogAttrs(dfltCtx(), slog.LevelWarn, message, attrs...)
 }
 
 // Warnf is [clog.Warnf] using the default logger.
 func Warnf(format string, args ...any) {
-	internal.Logf(context.Background(), slog.LevelWarn, format, args...)
+	internal.Logf(dfltCtx(), slog.LevelWarn, format, args...)
+}
+
+// With returns the default logger with the given attributes. The arguments are handled according
+// to [slog.Logger.With]. The returned logger honors the process-wide dynamic level when it's used
+// with a context that has no level of its own.
+func With(args ...any) *slog.Logger {
+	return Default().With(args...)
+}
+
+// WithGroup returns the default logger with the given group. The returned logger honors the process-wide
+// dynamic level when it's used with a context that has no level of its own.
+func WithGroup(name string) *slog.Logger {
+	return Default().WithGroup(name)
+}
+
+// rootTreeHandler passes the process-wide dynamic level on to its handler when it's called with a
+// context that has no level of its own.
+type rootTreeHandler struct {
+	slog.Handler
+}
+
+func (h *rootTreeHandler) withTree(ctx context.Context) context.Context {
+	if internal.TreeFrom(ctx) == nil {
+		ctx = internal.AttachTree(ctx, internal.TreeFrom(rootCtx))
+	}
+	return ctx
+}
+
+func (h *rootTreeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.Handler.Enabled(h.withTree(ctx), level)
+}
+
+func (h *rootTreeHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.Handler.Handle(h.withTree(ctx), record)
+}
+
+// Unwrap returns the handler of the default logger, so that Flush and Close reach it.
+func (h *rootTreeHandler) Unwrap() slog.Handler {
+	return h.Handler
+}
+
+func (h *rootTreeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &rootTreeHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *rootTreeHandler) WithGroup(name string) slog.Handler {
+	return &rootTreeHandler{Handler: h.Handler.WithGroup(name)}
 }
@@ -1,14 +1,16 @@
 package log_test
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 
+	"github.com/telepresenceio/clog"
 	"github.com/telepresenceio/clog/handler"
 	"github.com/telepresenceio/clog/log"
 )
 
-func ExampleWithLogger_slogTextHandler() {
+func ExampleInfof_slogTextHandler() {
 	// Use a custom handler to remove the time attribute (we can't use timestamps in an example).
 	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
 		if a.Key == slog.TimeKey {
@@ -29,7 +31,7 @@ func ExampleWithLogger_slogTextHandler() {
 	// level=INFO msg="Hello, world!" scope=universe
 }
 
-func ExampleWithLogger_clogTextHandler() {
+func ExampleInfof_clogTextHandler() {
 	slog.SetDefault(slog.New(handler.NewText(handler.TimeFormat(""), handler.EnabledLevel(slog.LevelInfo))))
 
 	log.Infof("Hello, %s!", "world")
@@ -38,6 +40,42 @@ func ExampleWithLogger_clogTextHandler() {
 	log.Infof("Hello, %s!", "world")
 
 	// Output:
-	// info  Hello, world!
-	// info  first: Hello, world!
+	// INFO  Hello, world!
+	// INFO  first: Hello, world!
+}
+
+func ExampleSetLevel() {
+	log.SetDefault(slog.New(handler.NewText(handler.TimeFormat(""), handler.LevelEnabler(clog.TreeEnabled))))
+	defer log.SetDefault(nil)
+
+	log.Debug("not logged")
+	log.Default().Debug("not logged")
+	log.SetLevel(slog.LevelDebug)
+	log.Debug("logged", "enabled", log.Enabled(slog.LevelDebug))
+	log.Default().Debug("logged by the default logger")
+	log.With("component", "db").Info("connected")
+	log.WithGroup("cache").Warn("cold")
+	log.ErrorAttrs("failed", slog.Int("attempts", 3))
+	log.SetLevel(slog.LevelInfo)
+
+	// Output:
+	// DEBUG logged : enabled=true
+	// DEBUG logged by the default logger
+	// INFO  connected : component=db
+	// WARN  cache: cold
+	// ERROR failed : attempts=3
+}
+
+func ExamplePanic() {
+	log.SetDefault(slog.New(handler.NewText(handler.TimeFormat(""), handler.EnabledLevel(slog.LevelInfo))))
+	defer log.SetDefault(nil)
+
+	defer func() {
+		fmt.Println("recovered:", recover())
+	}()
+	log.Panicf("inconsistent state: %d", 42)
+
+	// Output:
+	// ERROR+2 inconsistent state: 42
+	// recovered: inconsistent state: 42
 }
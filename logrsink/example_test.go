@@ -0,0 +1,32 @@
+package logrsink_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/telepresenceio/clog"
+	"github.com/telepresenceio/clog/handler"
+	"github.com/telepresenceio/clog/logrsink"
+)
+
+func ExampleNew() {
+	lg := slog.New(handler.NewText(handler.TimeFormat(""), handler.EnabledLevel(slog.LevelDebug)))
+	ctx := clog.WithLogger(context.Background(), lg)
+
+	sink := logrsink.New(ctx).WithName("client-go").WithValues("cluster", "dev")
+	sink.Init(logrsink.RuntimeInfo{CallDepth: 1})
+
+	sink.Info(0, "watching pods", "namespace", "default")
+	sink.Info(1, "cache synced")
+	if !sink.Enabled(2) {
+		sink.Info(0, "V(2) is disabled")
+	}
+	sink.Error(errors.New("connection refused"), "watch failed")
+
+	// Output:
+	// INFO  client-go: watching pods : cluster=dev namespace=default
+	// DEBUG client-go: cache synced : cluster=dev
+	// INFO  client-go: V(2) is disabled : cluster=dev
+	// ERROR client-go: watch failed : cluster=dev error="connection refused"
+}
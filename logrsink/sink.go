@@ -0,0 +1,115 @@
+// Package logrsink provides an implementation of the method set of the [logr] LogSink interface that
+// sends records to a logger obtained from [clog.Logger].
+//
+// The package is dependency-free, and since the LogSink interface refers to types in the logr package,
+// [Sink] cannot implement it directly. A program that uses logr can do that with a small shim:
+//
+//	type sink struct{ *logrsink.Sink }
+//
+//	func (s sink) Init(info logr.RuntimeInfo)       { s.Sink.Init(logrsink.RuntimeInfo(info)) }
+//	func (s sink) WithValues(kv ...any) logr.LogSink { return sink{s.Sink.WithValues(kv...)} }
+//	func (s sink) WithName(name string) logr.LogSink { return sink{s.Sink.WithName(name)} }
+//
+//	logger := logr.New(sink{logrsink.New(ctx).WithCallDepth(1)})
+//
+// The WithCallDepth(1) accounts for the extra stack frame added by the shim.
+//
+// [logr]: https://pkg.go.dev/github.com/go-logr/logr
+package logrsink
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"github.com/telepresenceio/clog"
+	"github.com/telepresenceio/clog/internal"
+)
+
+// RuntimeInfo has the same underlying type as logr.RuntimeInfo and can be converted from it.
+type RuntimeInfo struct {
+	// CallDepth is the number of call frames the logr library adds between the end-user and the Sink.
+	CallDepth int
+}
+
+// Sink sends records to a [slog.Logger].
+type Sink struct {
+	ctx       context.Context
+	logger    *slog.Logger
+	callDepth int
+}
+
+// New returns a Sink that sends records to the context logger. The context is also passed on to the
+// logger's handler, so that options such as [handler.LevelEnabler] work as expected.
+func New(ctx context.Context) *Sink {
+	return &Sink{ctx: ctx, logger: clog.Logger(ctx)}
+}
+
+// Level returns the [slog.Level] that corresponds to a logr V-level. V(0) is [slog.LevelInfo], V(1) is
+// [slog.LevelDebug], V(2) is [clog.LevelTrace], and higher V-levels are increasingly verbose levels below
+// [clog.LevelTrace].
+func Level(v int) slog.Level {
+	switch {
+	case v <= 0:
+		return slog.LevelInfo
+	case v == 1:
+		return slog.LevelDebug
+	default:
+		return clog.LevelTrace - slog.Level(v-2)
+	}
+}
+
+// Init receives runtime info about the logr library.
+func (s *Sink) Init(info RuntimeInfo) {
+	s.callDepth += info.CallDepth
+}
+
+// Enabled tests whether this Sink is enabled at the specified V-level.
+func (s *Sink) Enabled(level int) bool {
+	return s.logger.Handler().Enabled(s.ctx, Level(level))
+}
+
+// Info logs a non-error message at the specified V-level with the given key/value pairs as context.
+func (s *Sink) Info(level int, msg string, keysAndValues ...any) {
+	s.log(Level(level), msg, keysAndValues)
+}
+
+// Error logs an error, with the given message and key/value pairs as context, at [slog.LevelError].
+// The error is added using the key "error".
+func (s *Sink) Error(err error, msg string, keysAndValues ...any) {
+	s.log(slog.LevelError, msg, append([]any{slog.Any("error", err)}, keysAndValues...))
+}
+
+func (s *Sink) log(level slog.Level, msg string, keysAndValues []any) {
+	h := s.logger.Handler()
+	if !h.Enabled(s.ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3+s.callDepth, pcs[:]) // skip [Callers, log, Info or Error] and the frames of the logr library
+	r := slog.NewRecord(internal.Now(s.ctx), level, msg, pcs[0])
+	r.Add(keysAndValues...)
+	_ = h.Handle(s.ctx, r)
+}
+
+// WithValues returns a new Sink with additional key/value pairs.
+func (s *Sink) WithValues(keysAndValues ...any) *Sink {
+	s2 := *s
+	s2.logger = s.logger.With(keysAndValues...)
+	return &s2
+}
+
+// WithName returns a new Sink with the specified name appended. The name is added as a group.
+func (s *Sink) WithName(name string) *Sink {
+	s2 := *s
+	s2.logger = s.logger.WithGroup(name)
+	return &s2
+}
+
+// WithCallDepth returns a new Sink that skips the given number of additional stack frames when
+// determining the source of a record.
+func (s *Sink) WithCallDepth(depth int) *Sink {
+	s2 := *s
+	s2.callDepth += depth
+	return &s2
+}
@@ -0,0 +1,19 @@
+package clog_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// helperEnv makes the test binary act as the command run by [ExampleCommand], so that the example doesn't
+// depend on the programs that are installed.
+const helperEnv = "CLOG_TEST_HELPER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "exit3" {
+		fmt.Println("connected")
+		os.Exit(3)
+	}
+	os.Exit(m.Run())
+}
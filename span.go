@@ -0,0 +1,48 @@
+package clog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/telepresenceio/clog/internal"
+)
+
+// Start is [StartLevel] using [slog.LevelDebug].
+func Start(ctx context.Context, op string, attrs ...slog.Attr) (context.Context, func(*error)) {
+	ctx = WithGroup(ctx, op)
+	start := internal.Now(ctx)
+	internal.LogAttrs(ctx, slog.LevelDebug, "started", attrs...)
+	return ctx, doneFunc(ctx, slog.LevelDebug, start)
+}
+
+// StartLevel logs the start of the operation op at the given level, and returns a child context with a group
+// named after op, together with a function that must be called when the operation completes. Records logged
+// using the returned context, including those of nested operations, are logged in that group.
+//
+// The returned function takes a pointer to the error returned by the operation, or nil. It logs the duration
+// of the operation at the given level if the pointer or the error that it points to is nil, and at
+// [slog.LevelError] together with the error otherwise. It is typically deferred:
+//
+//	func connect(ctx context.Context) (err error) {
+//		ctx, done := clog.Start(ctx, "connect", slog.String("host", host))
+//		defer done(&err)
+//		...
+//	}
+func StartLevel(ctx context.Context, level slog.Level, op string, attrs ...slog.Attr) (context.Context, func(*error)) {
+	ctx = WithGroup(ctx, op)
+	start := internal.Now(ctx)
+	internal.LogAttrs(ctx, level, "started", attrs...)
+	return ctx, doneFunc(ctx, level, start)
+}
+
+func doneFunc(ctx context.Context, level slog.Level, start time.Time) func(*error) {
+	return func(errp *error) {
+		duration := slog.Duration("duration", internal.Now(ctx).Sub(start))
+		if errp != nil && *errp != nil {
+			internal.LogAttrs(ctx, slog.LevelError, "failed", duration, slog.Any("error", *errp))
+		} else {
+			internal.LogAttrs(ctx, level, "done", duration)
+		}
+	}
+}
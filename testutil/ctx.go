@@ -1,21 +1,109 @@
+// Package testutil provides loggers for tests. [NewContext] returns a context with a logger that writes to
+// the test output and fails the test according to a policy, and [Golden] compares the log output of a test
+// with a golden file.
+//
+// The golden files are updated by running the tests with the CLOG_UPDATE_GOLDEN environment variable set,
+// e.g. CLOG_UPDATE_GOLDEN=1 go test ./... A command line flag isn't used, because a library that defines a
+// flag makes every test binary that imports it panic if the binary defines a flag with the same name, and
+// -update is a common flag name in tests.
 package testutil
 
 import (
 	"context"
+	"io"
 	"log/slog"
+	"math"
+	"regexp"
+	"sync"
 	"testing"
 
 	"github.com/telepresenceio/clog"
 	"github.com/telepresenceio/clog/handler"
 )
 
+// ContextOption configures the failure policy of the logger created by [NewContext].
+type ContextOption func(*policy)
+
+// FailLevel makes records logged at the given level or above propagate to t.Error instead of being logged.
+// It overrides the failOnError argument of [NewContext].
+func FailLevel(level slog.Level) ContextOption {
+	return func(p *policy) {
+		p.failLevel = level
+	}
+}
+
+// Allow prevents records with a message matching any of the given regular expressions from failing the test.
+// It panics if a pattern cannot be compiled.
+func Allow(patterns ...string) ContextOption {
+	return func(p *policy) {
+		for _, pattern := range patterns {
+			p.allow = append(p.allow, regexp.MustCompile(pattern))
+		}
+	}
+}
+
+// Expect makes the test fail when it completes unless, for each of the given regular expressions, a record
+// with a matching message was logged. Expected messages never fail the test when logged.
+// It panics if a pattern cannot be compiled.
+func Expect(patterns ...string) ContextOption {
+	return func(p *policy) {
+		for _, pattern := range patterns {
+			p.expect = append(p.expect, &expectation{rx: regexp.MustCompile(pattern)})
+		}
+	}
+}
+
+// DumpOnFailure keeps the last n log lines in memory instead of writing them to t.Output, and
+// writes them only if the test has failed when it completes.
+func DumpOnFailure(n int) ContextOption {
+	return func(p *policy) {
+		p.dumpLines = n
+	}
+}
+
+type expectation struct {
+	rx   *regexp.Regexp
+	seen bool
+}
+
+type policy struct {
+	sync.Mutex
+	failLevel slog.Level
+	allow     []*regexp.Regexp
+	expect    []*expectation
+	dumpLines int
+}
+
+// check records the message as seen by matching expectations and returns true if it should fail the test.
+func (p *policy) check(record *slog.Record) bool {
+	p.Lock()
+	defer p.Unlock()
+	allowed := false
+	for _, e := range p.expect {
+		if e.rx.MatchString(record.Message) {
+			e.seen = true
+			allowed = true
+		}
+	}
+	if allowed || record.Level < p.failLevel {
+		return false
+	}
+	for _, rx := range p.allow {
+		if rx.MatchString(record.Message) {
+			return false
+		}
+	}
+	return true
+}
+
 type trapError struct {
 	slog.Handler
-	t *testing.T
+	t testing.TB
+	p *policy
 }
 
 func (t *trapError) Handle(ctx context.Context, record slog.Record) (err error) {
-	if record.Level >= slog.LevelError {
+	if t.p.check(&record) {
 		args := make([]any, 0, 1+record.NumAttrs())
 		args = append(args, record.Message)
 		record.Attrs(func(a slog.Attr) bool { args = append(args, a); return true })
@@ -26,12 +114,126 @@ func (t *trapError) Handle(ctx context.Context, record slog.Record) (err error)
 	return err
 }
 
+// Unwrap returns the handler that receives the records that don't fail the test.
+func (t *trapError) Unwrap() slog.Handler {
+	return t.Handler
+}
+
+func (t *trapError) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &trapError{Handler: t.Handler.WithAttrs(attrs), t: t.t, p: t.p}
+}
+
+func (t *trapError) WithGroup(name string) slog.Handler {
+	return &trapError{Handler: t.Handler.WithGroup(name), t: t.t, p: t.p}
+}
+
+// lineRing is a LevelWriter that keeps the last lines written to it.
+type lineRing struct {
+	sync.Mutex
+	lines [][]byte
+	next  int
+	full  bool
+}
+
+func (r *lineRing) Write(_ slog.Level, data []byte) (int, error) {
+	line := append([]byte(nil), data...)
+	r.Lock()
+	r.lines[r.next] = line
+	r.next++
+	if r.next == len(r.lines) {
+		r.next = 0
+		r.full = true
+	}
+	r.Unlock()
+	return len(data), nil
+}
+
+func (r *lineRing) dump(w io.Writer) {
+	r.Lock()
+	defer r.Unlock()
+	if r.full {
+		for _, line := range r.lines[r.next:] {
+			_, _ = w.Write(line)
+		}
+	}
+	for _, line := range r.lines[:r.next] {
+		_, _ = w.Write(line)
+	}
+}
+
 // NewContext returns a context with a logger that writes to t.
-// If failOnError is true, errors are propagated to t.Error instead of being logged.
-func NewContext(t *testing.T, failOnError bool) context.Context {
-	h := handler.NewText(handler.Output(t.Output()), handler.EnabledLevel(slog.LevelDebug))
+// If failOnError is true, errors are propagated to t.Error instead of being logged. The failure
+// policy can be further configured using options. Functions such as [clog.Fatal] fail the test and end the
+// calling goroutine instead of terminating the test binary, see [ExitCode].
+func NewContext(t testing.TB, failOnError bool, opts ...ContextOption) context.Context {
+	p := &policy{failLevel: slog.Level(math.MaxInt)}
 	if failOnError {
-		h = &trapError{Handler: h, t: t}
+		p.failLevel = slog.LevelError
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	out := handler.Output(t.Output())
+	if p.dumpLines > 0 {
+		ring := &lineRing{lines: make([][]byte, p.dumpLines)}
+		out = handler.LevelOutput(ring)
+		t.Cleanup(func() {
+			if t.Failed() {
+				ring.dump(t.Output())
+			}
+		})
+	}
+	if len(p.expect) > 0 {
+		// Registered after the dump so that it runs before it.
+		t.Cleanup(func() {
+			p.Lock()
+			defer p.Unlock()
+			for _, e := range p.expect {
+				if !e.seen {
+					t.Errorf("expected a log message matching %q", e.rx)
+				}
+			}
+		})
+	}
+
+	var h slog.Handler = handler.NewText(out, handler.EnabledLevel(slog.LevelDebug))
+	if p.failLevel != slog.Level(math.MaxInt) || len(p.expect) > 0 {
+		h = &trapError{Handler: h, t: t, p: p}
+	}
+	ctx := clog.WithLogger(context.Background(), slog.New(h))
+
+	// Fail the test instead of terminating the test binary when a fatal error is logged.
+	ex := &exit{}
+	ctx = context.WithValue(ctx, exitKey{}, ex)
+	return clog.WithExitFunc(ctx, func(code int) {
+		ex.Lock()
+		ex.code, ex.exited = code, true
+		ex.Unlock()
+		t.Errorf("exit with status %d", code)
+		// FailNow ends the calling goroutine using runtime.Goexit. Unlike a direct call to runtime.Goexit, it
+		// also marks the test as finished, which the testing package requires when the goroutine is the test
+		// goroutine.
+		t.FailNow()
+	})
+}
+
+type exitKey struct{}
+
+type exit struct {
+	sync.Mutex
+	code   int
+	exited bool
+}
+
+// ExitCode returns the exit code given to the exit function of a context created by [NewContext], e.g. by
+// [clog.Fatal], and true if the exit function has been called.
+func ExitCode(ctx context.Context) (int, bool) {
+	ex, ok := ctx.Value(exitKey{}).(*exit)
+	if !ok {
+		return 0, false
 	}
-	return clog.WithLogger(context.Background(), slog.New(h))
+	ex.Lock()
+	defer ex.Unlock()
+	return ex.code, ex.exited
 }
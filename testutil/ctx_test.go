@@ -0,0 +1,166 @@
+package testutil_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/telepresenceio/clog"
+	"github.com/telepresenceio/clog/testutil"
+)
+
+func TestNewContext_policy(t *testing.T) {
+	ctx := testutil.NewContext(t, true,
+		testutil.Allow(`^connection reset`),
+		testutil.Expect(`^retrying in \d+s$`),
+		testutil.DumpOnFailure(10))
+	ctx = clog.With(ctx, "attempt", 1)
+	clog.Error(ctx, "connection reset by peer")
+	clog.Errorf(ctx, "retrying in %ds", 2)
+	clog.Info(ctx, "connected")
+}
+
+// fakeTB records the failures of a test instead of failing it.
+type fakeTB struct {
+	testing.TB
+	sync.Mutex
+	errors   []string
+	failNow  bool
+	cleanups []func()
+	out      bytes.Buffer
+}
+
+func (f *fakeTB) FailNow() {
+	f.Lock()
+	f.failNow = true
+	f.Unlock()
+	runtime.Goexit()
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Error(args ...any) {
+	f.Lock()
+	f.errors = append(f.errors, strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+	f.Unlock()
+}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.Lock()
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+	f.Unlock()
+}
+
+func (f *fakeTB) Failed() bool {
+	f.Lock()
+	defer f.Unlock()
+	return len(f.errors) > 0
+}
+
+func (f *fakeTB) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+func (f *fakeTB) Output() io.Writer {
+	return &f.out
+}
+
+// finish runs the cleanup functions in the order used by testing.T.
+func (f *fakeTB) finish() {
+	for _, fn := range slices.Backward(f.cleanups) {
+		fn()
+	}
+}
+
+func TestNewContext_failLevel(t *testing.T) {
+	tb := &fakeTB{TB: t}
+	ctx := testutil.NewContext(tb, false, testutil.FailLevel(slog.LevelWarn))
+	clog.Info(ctx, "connected")
+	clog.Warn(ctx, "slow response", "ms", 1500)
+	tb.finish()
+	if want := []string{"slow response ms=1500"}; !slices.Equal(tb.errors, want) {
+		t.Errorf("got errors %q, want %q", tb.errors, want)
+	}
+	if out := tb.out.String(); !strings.Contains(out, "connected") || strings.Contains(out, "slow response") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestNewContext_unmetExpect(t *testing.T) {
+	tb := &fakeTB{TB: t}
+	ctx := testutil.NewContext(tb, true, testutil.Expect(`^ready$`, `^connected$`))
+	clog.Info(ctx, "connected")
+	tb.finish()
+	if want := []string{`expected a log message matching "^ready$"`}; !slices.Equal(tb.errors, want) {
+		t.Errorf("got errors %q, want %q", tb.errors, want)
+	}
+}
+
+func TestNewContext_dumpOnFailure(t *testing.T) {
+	for _, fail := range []bool{false, true} {
+		t.Run(fmt.Sprintf("fail=%t", fail), func(t *testing.T) {
+			tb := &fakeTB{TB: t}
+			ctx := testutil.NewContext(tb, true, testutil.DumpOnFailure(2))
+			clog.Info(ctx, "one")
+			clog.Info(ctx, "two")
+			clog.Info(ctx, "three")
+			if fail {
+				clog.Error(ctx, "boom")
+			}
+			tb.finish()
+			out := tb.out.String()
+			if !fail {
+				if out != "" {
+					t.Errorf("got output %q from a passing test", out)
+				}
+				return
+			}
+			if strings.Contains(out, "one") || !strings.Contains(out, "two") || !strings.Contains(out, "three") {
+				t.Errorf("got output %q, want the last two lines", out)
+			}
+		})
+	}
+}
+
+func TestNewContext_fatal(t *testing.T) {
+	t.Run("test goroutine", func(t *testing.T) {
+		tb := &fakeTB{TB: t}
+		var ctx context.Context
+		done := make(chan struct{})
+		go func() {
+			// This goroutine acts as the test goroutine of tb.
+			defer close(done)
+			ctx = testutil.NewContext(tb, false)
+			clog.Fatal(ctx, "unable to start")
+			t.Error("Fatal returned")
+		}()
+		<-done
+		if code, ok := testutil.ExitCode(ctx); !ok || code != 1 || !tb.failNow {
+			t.Errorf("got exit code %d, %t, and FailNow %t", code, ok, tb.failNow)
+		}
+	})
+	t.Run("other goroutine", func(t *testing.T) {
+		tb := &fakeTB{TB: t}
+		ctx := testutil.NewContext(tb, false)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			clog.Fatal(ctx, "unable to start")
+			t.Error("Fatal returned")
+		}()
+		<-done
+		if want := []string{"exit with status 1"}; !slices.Equal(tb.errors, want) || !tb.failNow {
+			t.Errorf("got errors %q and FailNow %t", tb.errors, tb.failNow)
+		}
+		if code, ok := testutil.ExitCode(ctx); !ok || code != 1 {
+			t.Errorf("got exit code %d, %t", code, ok)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package testutil_test
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/telepresenceio/clog"
+	"github.com/telepresenceio/clog/handler"
+	"github.com/telepresenceio/clog/testutil"
+)
+
+func ExampleWithClock() {
+	clock := testutil.NewFakeClock(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	lg := slog.New(handler.NewText(handler.TimeFormat("15:04:05.000"), handler.EnabledLevel(slog.LevelInfo)))
+	ctx := testutil.WithClock(clog.WithLogger(context.Background(), lg), clock)
+
+	clog.Info(ctx, "connecting")
+	clock.Advance(1500 * time.Millisecond)
+	clog.Info(ctx, "connected")
+
+	// Output:
+	// 03:04:05.000 INFO  connecting
+	// 03:04:06.500 INFO  connected
+}
@@ -0,0 +1,180 @@
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/telepresenceio/clog"
+	"github.com/telepresenceio/clog/handler"
+)
+
+// UpdateGoldenEnv is the environment variable that makes [Golden] write the golden files instead of comparing
+// them, when it is set to a non-empty value other than "0" or "false".
+const UpdateGoldenEnv = "CLOG_UPDATE_GOLDEN"
+
+func updateGolden() bool {
+	v := os.Getenv(UpdateGoldenEnv)
+	return v != "" && v != "0" && v != "false"
+}
+
+// Normalizer rewrites the captured log output before it is compared with a golden file.
+type Normalizer func([]byte) []byte
+
+// GoldenOption configures the behavior of [Golden].
+type GoldenOption func(*golden)
+
+// HandlerOptions adds options to the [handler.NewText] handler used by [Golden]. The output is always
+// set to the capturing buffer, and the enabled level defaults to [clog.LevelTrace].
+func HandlerOptions(opts ...handler.Option) GoldenOption {
+	return func(g *golden) {
+		g.handlerOpts = append(g.handlerOpts, opts...)
+	}
+}
+
+// Normalize adds normalizers that are applied, in order, after the default normalizers.
+func Normalize(normalizers ...Normalizer) GoldenOption {
+	return func(g *golden) {
+		g.normalizers = append(g.normalizers, normalizers...)
+	}
+}
+
+// NoDefaultNormalizers removes the normalizers returned by [DefaultNormalizers].
+func NoDefaultNormalizers() GoldenOption {
+	return func(g *golden) {
+		g.normalizers = nil
+	}
+}
+
+// ReplaceAll returns a Normalizer that replaces all matches of the regular expression with repl. Inside
+// repl, $ signs are interpreted as in [regexp.Regexp.Expand].
+func ReplaceAll(re *regexp.Regexp, repl string) Normalizer {
+	rb := []byte(repl)
+	return func(data []byte) []byte {
+		return re.ReplaceAll(data, rb)
+	}
+}
+
+var (
+	timestampRx = regexp.MustCompile(`(\d{4}-\d{2}-\d{2}[T ])?\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+	sourceRx    = regexp.MustCompile(`\(from (?:[^()\s]*[/\\])?([^/\\()\s]+):(\d+)\)`)
+	goroutineRx = regexp.MustCompile(`goroutine \d+`)
+	durationRx  = regexp.MustCompile(`\b(\d+(\.\d+)?(ns|us|µs|ms|s|m|h))+\b`)
+)
+
+// NormalizeTimestamps replaces timestamps such as "2026-01-02T03:04:05.678" or "03:04:05.6789" with "<time>".
+func NormalizeTimestamps() Normalizer {
+	return ReplaceAll(timestampRx, "<time>")
+}
+
+// NormalizeSourcePaths removes the directory from the "(from file:line)" source information.
+func NormalizeSourcePaths() Normalizer {
+	return ReplaceAll(sourceRx, "(from $1:$2)")
+}
+
+// NormalizeGoroutineIDs replaces "goroutine <id>" with "goroutine N".
+func NormalizeGoroutineIDs() Normalizer {
+	return ReplaceAll(goroutineRx, "goroutine N")
+}
+
+// NormalizeDurations replaces durations formatted by [time.Duration.String], such as "1m2.5s" or "12ms", with "<duration>".
+func NormalizeDurations() Normalizer {
+	return ReplaceAll(durationRx, "<duration>")
+}
+
+// DefaultNormalizers returns the normalizers used by [Golden] unless [NoDefaultNormalizers] is given.
+func DefaultNormalizers() []Normalizer {
+	return []Normalizer{
+		NormalizeTimestamps(),
+		NormalizeSourcePaths(),
+		NormalizeGoroutineIDs(),
+		NormalizeDurations(),
+	}
+}
+
+type golden struct {
+	handlerOpts []handler.Option
+	normalizers []Normalizer
+}
+
+type syncBuffer struct {
+	sync.Mutex
+	bytes.Buffer
+}
+
+func (b *syncBuffer) Write(data []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.Buffer.Write(data)
+}
+
+// Golden returns a context with a logger that captures the output of a [handler.NewText] handler. When the test
+// completes, the captured output is normalized and compared with the file testdata/<name>.golden, and the test
+// fails if they differ. The golden file is written instead of compared when the [UpdateGoldenEnv]
+// environment variable is set, e.g. by running CLOG_UPDATE_GOLDEN=1 go test ./...
+func Golden(t testing.TB, name string, opts ...GoldenOption) context.Context {
+	t.Helper()
+	g := golden{normalizers: DefaultNormalizers()}
+	for _, opt := range opts {
+		opt(&g)
+	}
+	buf := &syncBuffer{}
+	hOpts := append([]handler.Option{handler.EnabledLevel(clog.LevelTrace)}, g.handlerOpts...)
+	hOpts = append(hOpts, handler.Output(buf))
+	t.Cleanup(func() {
+		buf.Lock()
+		data := buf.Bytes()
+		buf.Unlock()
+		g.check(t, filepath.Join("testdata", name+".golden"), data)
+	})
+	return clog.WithLogger(context.Background(), slog.New(handler.NewText(hOpts...)))
+}
+
+func (g *golden) check(t testing.TB, path string, data []byte) {
+	t.Helper()
+	for _, n := range g.normalizers {
+		data = n(data)
+	}
+	if updateGolden() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read golden file (set CLOG_UPDATE_GOLDEN=1 to create it): %v", err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("log output differs from %s (set CLOG_UPDATE_GOLDEN=1 to accept):\n%s", path, lineDiff(want, data))
+	}
+}
+
+// lineDiff returns a minimal report of the lines that differ between want and got.
+func lineDiff(want, got []byte) string {
+	wl := bytes.Split(want, []byte{'\n'})
+	gl := bytes.Split(got, []byte{'\n'})
+	var out bytes.Buffer
+	for i := range max(len(wl), len(gl)) {
+		var w, g []byte
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if i >= len(wl) || i >= len(gl) || !bytes.Equal(w, g) {
+			_, _ = fmt.Fprintf(&out, "line %d:\n  want: %s\n  got:  %s\n", i+1, w, g)
+		}
+	}
+	return out.String()
+}
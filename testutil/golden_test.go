@@ -0,0 +1,35 @@
+package testutil_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/telepresenceio/clog"
+	"github.com/telepresenceio/clog/handler"
+	"github.com/telepresenceio/clog/testutil"
+)
+
+func TestGolden(t *testing.T) {
+	ctx := testutil.Golden(t, "golden", testutil.HandlerOptions(handler.IncludeSource(true)))
+	clog.Info(ctx, "starting")
+	clog.Debugf(ctx, "connected after %s", 1234*time.Millisecond)
+	clog.Trace(clog.WithGroup(ctx, "worker"), "goroutine 17 waiting")
+}
+
+func TestGolden_update(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(testutil.UpdateGoldenEnv, "1")
+	t.Run("write", func(t *testing.T) {
+		clog.Info(testutil.Golden(t, "updated"), "written")
+	})
+	data, err := os.ReadFile(filepath.Join("testdata", "updated.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "INFO  written\n"; !strings.HasSuffix(string(data), want) {
+		t.Errorf("got %q, want suffix %q", data, want)
+	}
+}
@@ -1,14 +1,70 @@
 package testutil
 
 import (
+	"context"
+	"sync"
+	"testing"
 	"time"
 
 	"github.com/telepresenceio/clog/internal"
 )
 
+// Clock provides the time used for log records created by clog and clog/log functions.
+type Clock = internal.Clock
+
+// WithClock assigns the clock to a child context which is returned. Log records created by clog functions
+// using that context, or any of its children, will use the time provided by the clock. Unlike
+// [SetTimeProvider], this is safe to use from parallel tests.
+func WithClock(ctx context.Context, clock Clock) context.Context {
+	return internal.WithClock(ctx, clock)
+}
+
+// FakeClock is a [Clock] that only moves when told to. It is safe for concurrent use.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock returns a FakeClock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by the given duration.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// Set sets the current time of the clock.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	c.now = now
+	c.mu.Unlock()
+}
+
 // SetTimeProvider overrides the default time provider [time.Now] with the given function.
 // Affects all clog and clog/log functions but not slog functions.
 // For testing purposes only.
+//
+// Deprecated: The time provider is global and races with parallel tests. Use [WithClock] or [OverrideTimeProvider].
 func SetTimeProvider(tp func() time.Time) {
 	internal.TimeNow = tp
 }
+
+// OverrideTimeProvider overrides the default time provider [time.Now] with the given function and restores
+// it when the test completes. The time provider is global, so tests using this function must not be parallel.
+// Use [WithClock] in parallel tests.
+func OverrideTimeProvider(t testing.TB, tp func() time.Time) {
+	old := internal.TimeNow
+	internal.TimeNow = tp
+	t.Cleanup(func() { internal.TimeNow = old })
+}
@@ -2,16 +2,116 @@ package clog
 
 import (
 	"context"
+	stdLog "log"
 	"log/slog"
+	"runtime"
+	"strings"
+
+	"github.com/telepresenceio/clog/internal"
 )
 
+// StdLogOption configures the writer of a logger created by [StdLogger] or [RedirectStdLog].
+type StdLogOption func(*writer)
+
+// LevelPrefixes makes the writer use the level given by a prefix such as "[WARN] " or "ERROR: " in each
+// logged line, instead of the default level. The prefix is removed from the message. See [SplitLevelPrefix].
+func LevelPrefixes() StdLogOption {
+	return func(w *writer) {
+		w.levelPrefixes = true
+	}
+}
+
 type writer struct {
-	log   *slog.Logger
-	level slog.Level
-	ctx   context.Context
+	log           *slog.Logger
+	level         slog.Level
+	ctx           context.Context
+	levelPrefixes bool
+}
+
+func newWriter(ctx context.Context, level slog.Level, opts []StdLogOption) *writer {
+	w := &writer{log: Logger(ctx), level: level, ctx: ctx}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
 }
 
 func (w *writer) Write(p []byte) (n int, err error) {
-	w.log.Log(w.ctx, w.level, string(p))
+	msg := string(p)
+	msg = strings.TrimSuffix(msg, "\n")
+	msg = strings.TrimSuffix(msg, "\r")
+	level := w.level
+	if w.levelPrefixes {
+		if l, rest, ok := SplitLevelPrefix(msg); ok {
+			level, msg = l, rest
+		}
+	}
+	h := w.log.Handler()
+	if h.Enabled(w.ctx, level) {
+		_ = h.Handle(w.ctx, slog.NewRecord(internal.Now(w.ctx), level, msg, stdLogCaller()))
+	}
 	return len(p), nil
 }
+
+// stdLogCaller returns the program counter of the first caller outside the standard log package.
+func stdLogCaller() uintptr {
+	var pcs [16]uintptr
+	n := runtime.Callers(3, pcs[:]) // skip [Callers, stdLogCaller, writer.Write]
+	for _, pc := range pcs[:n] {
+		f, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		if !strings.HasPrefix(f.Function, "log.") {
+			return pc
+		}
+	}
+	return 0
+}
+
+// SplitLevelPrefix looks for a level prefix in the form "[LEVEL] " or "LEVEL: ", where LEVEL is a
+// string accepted by [ParseLevel] or "WARNING", using case-insensitive comparison. It returns the level,
+// the line without the prefix, and true if such a prefix was found.
+func SplitLevelPrefix(line string) (slog.Level, string, bool) {
+	var name, rest string
+	switch {
+	case strings.HasPrefix(line, "["):
+		end := strings.IndexByte(line, ']')
+		if end < 0 {
+			return 0, line, false
+		}
+		name = line[1:end]
+		rest = strings.TrimPrefix(line[end+1:], ":")
+	default:
+		end := strings.IndexByte(line, ':')
+		if end < 0 || strings.IndexByte(line[:end], ' ') >= 0 {
+			return 0, line, false
+		}
+		name = line[:end]
+		rest = line[end+1:]
+	}
+	if strings.EqualFold(name, "WARNING") {
+		name = "WARN"
+	}
+	level, err := ParseLevel(name)
+	if err != nil {
+		return 0, line, false
+	}
+	return level, strings.TrimLeft(rest, " \t"), true
+}
+
+// RedirectStdLog makes the standard log package send its output to the context logger, using the given
+// level unless a [LevelPrefixes] option is given and a prefix is found. The standard logger's flags and
+// prefix are cleared, since the time and source are provided by the context logger. The returned function
+// restores the standard logger's previous output, flags, and prefix.
+//
+// The context logger must not use the handler of the initial [slog.Default] logger, because that handler
+// writes to the standard logger.
+func RedirectStdLog(ctx context.Context, level slog.Level, opts ...StdLogOption) (restore func()) {
+	out, flags, prefix := stdLog.Writer(), stdLog.Flags(), stdLog.Prefix()
+	stdLog.SetOutput(newWriter(ctx, level, opts))
+	stdLog.SetFlags(0)
+	stdLog.SetPrefix("")
+	return func() {
+		stdLog.SetOutput(out)
+		stdLog.SetFlags(flags)
+		stdLog.SetPrefix(prefix)
+	}
+}